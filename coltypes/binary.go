@@ -0,0 +1,55 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coltypes
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// BinaryEncoding defines how binary values are represented as strings in records.
+type BinaryEncoding string
+
+const (
+	// BinaryRaw represents binary values as their bytes, strings are written as they are.
+	BinaryRaw BinaryEncoding = "raw"
+	// BinaryBase64 represents binary values as standard base64 strings.
+	BinaryBase64 BinaryEncoding = "base64"
+	// BinaryHex represents binary values as hexadecimal strings.
+	BinaryHex BinaryEncoding = "hex"
+)
+
+// decodeBinary decodes the string of a binary column according to the encoding.
+func decodeBinary(name, value string, encoding BinaryEncoding) ([]byte, error) {
+	switch encoding {
+	case BinaryBase64:
+		bs, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: decode base64: %v", invalidValueErr(name, value), err)
+		}
+
+		return bs, nil
+	case BinaryHex:
+		bs, err := hex.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: decode hex: %v", invalidValueErr(name, value), err)
+		}
+
+		return bs, nil
+	default:
+		return []byte(value), nil
+	}
+}
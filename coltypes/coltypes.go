@@ -19,9 +19,10 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"reflect"
-	"strconv"
 	"strings"
 	"time"
 
@@ -40,9 +41,20 @@ const (
 	varcharType        = "VARCHAR"
 	longVarGraphicType = "LONG VARGRAPHIC"
 	varGraphicType     = "VARGRAPHIC"
+	dbclobType         = "DBCLOB"
+	xmlType            = "XML"
 	decimalType        = "DECIMAL"
+	numericType        = "NUMERIC"
 	decimalFloat       = "DECFLOAT"
 
+	// Numeric types.
+	booleanType  = "BOOLEAN"
+	smallintType = "SMALLINT"
+	integerType  = "INTEGER"
+	bigintType   = "BIGINT"
+	realType     = "REAL"
+	doubleType   = "DOUBLE"
+
 	// Time types.
 	date      = "DATE"
 	timeType  = "TIME"
@@ -52,22 +64,52 @@ const (
 	binary    = "BINARY"
 	varbinary = "VARBINARY"
 	blob      = "BLOB"
+	rowID     = "ROWID"
+)
+
+// column type definitions inferred from Go values.
+const (
+	// maxInferredVarcharLength is a maximum length of strings stored in inferred VARCHAR columns,
+	// longer strings are stored in CLOB columns.
+	maxInferredVarcharLength = 255
+
+	inferredVarcharType   = "VARCHAR(255)"
+	inferredClobType      = "CLOB(1M)"
+	inferredBooleanType   = "BOOLEAN"
+	inferredIntegerType   = "INTEGER"
+	inferredBigintType    = "BIGINT"
+	inferredDoubleType    = "DOUBLE"
+	inferredDecFloatType  = "DECFLOAT(34)"
+	inferredTimestampType = "TIMESTAMP"
+	inferredBlobType      = "BLOB(1M)"
 )
 
 var (
 	// querySchemaColumnTypes is a query that selects column names and
 	// their data and column types from the information_schema.
+	// Distinct types are selected as their source types along with their own names.
 	querySchemaColumnTypes = `
 			SELECT 
-				   colname as column_name,
-				   typename as data_type
+				   c.colname as column_name,
+				   coalesce(d.sourcename, c.typename) as data_type,
+				   coalesce(d.typename, '') as user_type,
+				   c.length,
+				   c.scale,
+				   c.codepage
+			from syscat.columns c
+			left join syscat.datatypes d
+				   on d.typeschema = c.typeschema and d.typename = c.typename and d.metatype = 'T'
+			where c.tabschema = coalesce(cast(? as varchar(128)), current schema) and c.tabname = ?
+`
+	// queryGeneratedAlwaysColumns is a query that selects names of the columns
+	// whose values are always generated by the database, e.g. GENERATED ALWAYS AS IDENTITY.
+	queryGeneratedAlwaysColumns = `
+			SELECT 
+				   colname as column_name
 			from syscat.columns
-			where tabname = '%s'
+			where tabschema = coalesce(cast(? as varchar(128)), current schema) and tabname = ?
+				   and generated = 'A'
 `
-	// time layouts.
-	layouts = []string{time.RFC3339, time.RFC3339Nano, time.Layout, time.ANSIC, time.UnixDate, time.RubyDate,
-		time.RFC822, time.RFC822Z, time.RFC850, time.RFC1123, time.RFC1123Z, time.RFC3339, time.RFC3339,
-		time.RFC3339Nano, time.Kitchen, time.Stamp, time.StampMilli, time.StampMicro, time.StampNano}
 )
 
 // Querier is a database querier interface needed for the GetColumnTypes function.
@@ -75,8 +117,65 @@ type Querier interface {
 	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
 }
 
-// TransformRow converts row map values to appropriate Go types, based on the columnTypes.
-func TransformRow(ctx context.Context, row map[string]any, columnTypes map[string]string) (map[string]any, error) {
+// ColumnType describes a type of a DB2 column.
+type ColumnType struct {
+	// Name is a name of the type, e.g. "VARCHAR". For distinct types it's the name of the source type.
+	Name string
+	// UserType is a name of the distinct type, e.g. "MONEY", it's empty for built-in types.
+	UserType string
+	// Length is a maximum length of string and binary types or a precision of decimal types.
+	Length int
+	// Scale is a scale of decimal types.
+	Scale int
+	// ForBitData reports whether a character type is defined FOR BIT DATA, i.e. it stores binary data.
+	ForBitData bool
+}
+
+// IsString reports whether the type stores strings, i.e. it is a character, graphic, CLOB or XML type.
+// Values of such types can hold serialized structured data.
+func (t ColumnType) IsString() bool {
+	switch t.Name {
+	case charType, longVarcharType, varcharType:
+		return !t.ForBitData
+	case clobType, graphicType, longVarGraphicType, varGraphicType, dbclobType, xmlType:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsBinary reports whether the type stores binary data, i.e. it is a binary, BLOB, ROWID or FOR BIT DATA type.
+func (t ColumnType) IsBinary() bool {
+	switch t.Name {
+	case binary, varbinary, blob, rowID:
+		return true
+	case charType, longVarcharType, varcharType:
+		return t.ForBitData
+	default:
+		return false
+	}
+}
+
+// IsLOB reports whether the type is a large object type, i.e. CLOB, DBCLOB or BLOB.
+func (t ColumnType) IsLOB() bool {
+	switch t.Name {
+	case clobType, dbclobType, blob:
+		return true
+	default:
+		return false
+	}
+}
+
+// TransformRow converts row map values to appropriate Go types, based on the columnTypes,
+// by the converters of the options' registry.
+// The built-in converters return strings for character, graphic, CLOB and XML types,
+// exact json.Number values for decimal types, and values of other types as they are.
+func TransformRow(
+	ctx context.Context,
+	row map[string]any,
+	columnTypes map[string]ColumnType,
+	opts Options,
+) (map[string]any, error) {
 	result := make(map[string]any, len(row))
 
 	for key, value := range row {
@@ -86,30 +185,26 @@ func TransformRow(ctx context.Context, row map[string]any, columnTypes map[strin
 			continue
 		}
 
-		switch columnTypes[key] {
-		// Convert to string.
-		case charType, clobType, longVarcharType, graphicType, longVarGraphicType,
-			varcharType, varGraphicType, decimalType, decimalFloat:
-			valueBytes, ok := value.([]byte)
-			if !ok {
-				return nil, convertValueToBytesErr(key)
-			}
+		columnType := columnTypes[key]
 
-			result[key] = string(valueBytes)
+		var err error
 
-		default:
-			result[key] = value
+		result[key], err = opts.registry().Lookup(columnType).Decode(key, value, columnType, opts)
+		if err != nil {
+			return nil, err
 		}
 	}
 
 	return result, nil
 }
 
-// ConvertStructureData converts a sdk.StructureData values to a proper database types.
+// ConvertStructureData converts a sdk.StructureData values to a proper database types
+// by the converters of the options' registry.
 func ConvertStructureData(
 	ctx context.Context,
-	columnTypes map[string]string,
+	columnTypes map[string]ColumnType,
 	data sdk.StructuredData,
+	opts Options,
 ) (sdk.StructuredData, error) {
 	result := make(sdk.StructuredData, len(data))
 
@@ -120,115 +215,157 @@ func ConvertStructureData(
 			continue
 		}
 
-		// DB2 doesn't have json type or similar.
-		// DB2 string types can replace it.
-		switch reflect.TypeOf(value).Kind() {
-		case reflect.Map, reflect.Slice:
-			bs, err := json.Marshal(value)
-			if err != nil {
-				return nil, fmt.Errorf("marshal: %w", err)
-			}
+		columnType := columnTypes[strings.ToUpper(key)]
 
-			result[key] = string(bs)
+		converted, err := opts.registry().Lookup(columnType).Encode(key, value, columnType, opts)
+		if err == nil {
+			err = checkLength(key, converted, columnType)
+		}
 
-			continue
+		if errors.Is(err, ErrValueOutOfRange) {
+			converted, err = handleOverflow(ctx, key, value, converted, columnType, opts.overflowPolicy(key), err)
 		}
 
-		// Converting value to time if it is string.
-		switch columnTypes[strings.ToUpper(key)] {
-		case date, timeType, timeStamp:
-			_, ok := value.(time.Time)
-			if ok {
-				result[key] = value
-
-				continue
-			}
-
-			valueStr, ok := value.(string)
-			if !ok {
-				return nil, ErrValueIsNotAString
-			}
-
-			timeValue, err := parseTime(valueStr)
-			if err != nil {
-				return nil, fmt.Errorf("convert value to time.Time: %w", err)
-			}
-
-			result[key] = timeValue
-		// DecimalFloat must be a number.
-		case decimalFloat:
-			switch v := value.(type) {
-			case float64:
-				result[key] = v
-			case float32:
-				result[key] = v
-			case int64:
-				result[key] = value.(float64)
-			case int32:
-				result[key] = value.(float32)
-			case string:
-				res, err := strconv.ParseFloat(value.(string), 64)
-				if err != nil {
-					return nil, fmt.Errorf("parse float: %w", err)
-				}
-
-				result[key] = res
-			default:
-				return nil, ErrConvertDecFloat
-			}
-
-		case binary, varbinary, blob:
-			_, ok := value.([]byte)
-			if ok {
-				result[key] = value
-
-				continue
-			}
-
-			valueStr, ok := value.(string)
-			if !ok {
-				return nil, ErrValueIsNotAString
-			}
-
-			result[key] = []byte(valueStr)
-
-		default:
-			result[key] = value
+		if err != nil {
+			return nil, err
 		}
+
+		result[key] = converted
 	}
 
 	return result, nil
 }
 
 // GetColumnTypes returns a map containing all table's columns and their database types.
-func GetColumnTypes(ctx context.Context, querier Querier, tableName string) (map[string]string, error) {
-	rows, err := querier.QueryContext(ctx, fmt.Sprintf(querySchemaColumnTypes, tableName))
+// The table name can be qualified by a schema, e.g. "SALES.CLIENTS", otherwise the current schema is used.
+func GetColumnTypes(ctx context.Context, querier Querier, tableName string) (map[string]ColumnType, error) {
+	schema, table := splitTableName(tableName)
+
+	rows, err := querier.QueryContext(ctx, querySchemaColumnTypes, schema, table)
 	if err != nil {
 		return nil, fmt.Errorf("query column types: %w", err)
 	}
+	defer rows.Close()
 
-	columnTypes := make(map[string]string)
+	columnTypes := make(map[string]ColumnType)
 	for rows.Next() {
-		var columnName, dataType string
-		if er := rows.Scan(&columnName, &dataType); er != nil {
+		var (
+			columnName string
+			columnType ColumnType
+			codePage   int
+		)
+
+		if er := rows.Scan(&columnName, &columnType.Name, &columnType.UserType,
+			&columnType.Length, &columnType.Scale, &codePage); er != nil {
 			return nil, fmt.Errorf("scan rows: %w", er)
 		}
 
-		columnTypes[columnName] = dataType
+		// character columns without a code page are defined FOR BIT DATA.
+		switch columnType.Name {
+		case charType, varcharType, longVarcharType:
+			columnType.ForBitData = codePage == 0
+		}
+
+		columnTypes[columnName] = columnType
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate rows: %w", err)
 	}
 
 	return columnTypes, nil
 }
 
-func parseTime(val string) (time.Time, error) {
-	for _, l := range layouts {
-		timeValue, err := time.Parse(l, val)
-		if err != nil {
-			continue
+// GetGeneratedAlwaysColumns returns a set of table's columns which values are always generated by the database.
+// Such columns cannot be used in INSERT statements. The table name is resolved as by the GetColumnTypes.
+func GetGeneratedAlwaysColumns(ctx context.Context, querier Querier, tableName string) (map[string]struct{}, error) {
+	schema, table := splitTableName(tableName)
+
+	rows, err := querier.QueryContext(ctx, queryGeneratedAlwaysColumns, schema, table)
+	if err != nil {
+		return nil, fmt.Errorf("query generated always columns: %w", err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]struct{})
+	for rows.Next() {
+		var columnName string
+		if er := rows.Scan(&columnName); er != nil {
+			return nil, fmt.Errorf("scan rows: %w", er)
 		}
 
-		return timeValue, nil
+		columns[columnName] = struct{}{}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate rows: %w", err)
+	}
+
+	return columns, nil
+}
+
+// splitTableName splits the table name qualified by a schema into the schema and the table.
+// The schema is nil if the name isn't qualified, so that the current schema is used by the catalog queries.
+func splitTableName(name string) (any, string) {
+	if schema, table, ok := strings.Cut(name, "."); ok {
+		return schema, table
+	}
+
+	return nil, name
+}
+
+// InferType returns a definition of a DB2 column type that can store the value, e.g. "VARCHAR(255)".
+// Strings in the RFC 3339 format are stored as timestamps, maps and slices are stored as JSON strings.
+func InferType(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return inferredVarcharType
+	case bool:
+		return inferredBooleanType
+	case int8, int16, int32, uint8, uint16:
+		return inferredIntegerType
+	case int, int64, uint, uint32, uint64:
+		return inferredBigintType
+	case float32:
+		return inferFloatType(float64(v))
+	case float64:
+		return inferFloatType(v)
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return inferredBigintType
+		}
+
+		// JSON numbers keep their digits, so they're stored exactly.
+		return inferredDecFloatType
+	case time.Time:
+		return inferredTimestampType
+	case []byte:
+		return inferredBlobType
+	case string:
+		if _, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return inferredTimestampType
+		}
+
+		if len(v) > maxInferredVarcharLength {
+			return inferredClobType
+		}
+
+		return inferredVarcharType
+	}
+
+	switch reflect.TypeOf(value).Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array:
+		return inferredClobType
+	default:
+		return inferredVarcharType
+	}
+}
+
+// inferFloatType returns BIGINT for whole numbers, that's how JSON integers are decoded, and DOUBLE otherwise.
+func inferFloatType(value float64) string {
+	if value == math.Trunc(value) && math.Abs(value) < math.MaxInt64 {
+		return inferredBigintType
 	}
 
-	return time.Time{}, fmt.Errorf("%s - %w", val, ErrInvalidTimeLayout)
+	return inferredDoubleType
 }
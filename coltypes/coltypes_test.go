@@ -15,7 +15,17 @@
 package coltypes
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	sdk "github.com/conduitio/conduit-connector-sdk"
 )
 
 func Test_parseToTime(t *testing.T) {
@@ -44,10 +54,12 @@ func Test_parseToTime(t *testing.T) {
 	}
 
 	for _, tt := range tests {
+		tt := tt
+
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			_, err := parseTime(tt.strValue)
+			_, _, err := parseTime(tt.strValue, Options{})
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
 
@@ -56,3 +68,893 @@ func Test_parseToTime(t *testing.T) {
 		})
 	}
 }
+
+func TestInferType(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{name: "nil", value: nil, want: "VARCHAR(255)"},
+		{name: "bool", value: true, want: "BOOLEAN"},
+		{name: "int32", value: int32(1), want: "INTEGER"},
+		{name: "int64", value: int64(1), want: "BIGINT"},
+		{name: "whole float", value: float64(42), want: "BIGINT"},
+		{name: "float", value: 4.2, want: "DOUBLE"},
+		{name: "integer number", value: json.Number("42"), want: "BIGINT"},
+		{name: "decimal number", value: json.Number("4.2"), want: "DECFLOAT(34)"},
+		{name: "exponent number", value: json.Number("1.5e-3"), want: "DECFLOAT(34)"},
+		{name: "big integer number", value: json.Number("123456789012345678901234567890"), want: "DECFLOAT(34)"},
+		{name: "time", value: time.Now(), want: "TIMESTAMP"},
+		{name: "timestamp string", value: "2014-11-12T11:45:26.371Z", want: "TIMESTAMP"},
+		{name: "string", value: "test", want: "VARCHAR(255)"},
+		{name: "long string", value: strings.Repeat("a", 256), want: "CLOB(1M)"},
+		{name: "bytes", value: []byte("test"), want: "BLOB(1M)"},
+		{name: "map", value: map[string]any{"city": "Kyiv"}, want: "CLOB(1M)"},
+		{name: "slice", value: []any{1, 2}, want: "CLOB(1M)"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := InferType(tt.value); got != tt.want {
+				t.Errorf("InferType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// convertTest is a test case of converting a single value.
+type convertTest struct {
+	name    string
+	value   any
+	want    any
+	wantErr bool
+}
+
+// runConvertStructureData runs ConvertStructureData test cases for a column of the type.
+func runConvertStructureData(t *testing.T, columnType ColumnType, tests []convertTest) {
+	t.Helper()
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ConvertStructureData(context.Background(),
+				map[string]ColumnType{"COL": columnType}, sdk.StructuredData{"col": tt.value}, Options{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ConvertStructureData() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && !reflect.DeepEqual(got["col"], tt.want) {
+				t.Errorf("ConvertStructureData() = %v (%T), want %v (%T)", got["col"], got["col"], tt.want, tt.want)
+			}
+		})
+	}
+}
+
+// runTransformRow runs TransformRow test cases for a column of the type.
+func runTransformRow(t *testing.T, columnType ColumnType, tests []convertTest) {
+	t.Helper()
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := TransformRow(context.Background(),
+				map[string]any{"COL": tt.value}, map[string]ColumnType{"COL": columnType}, Options{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TransformRow() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && !reflect.DeepEqual(got["COL"], tt.want) {
+				t.Errorf("TransformRow() = %v (%T), want %v (%T)", got["COL"], got["COL"], tt.want, tt.want)
+			}
+		})
+	}
+}
+
+func Test_splitTableName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		tableName  string
+		wantSchema any
+		wantTable  string
+	}{
+		{
+			name:      "unqualified",
+			tableName: "CLIENTS",
+			wantTable: "CLIENTS",
+		},
+		{
+			name:       "qualified",
+			tableName:  "SALES.CLIENTS",
+			wantSchema: "SALES",
+			wantTable:  "CLIENTS",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			schema, table := splitTableName(tt.tableName)
+			if schema != tt.wantSchema || table != tt.wantTable {
+				t.Errorf("splitTableName() = %v, %v, want %v, %v", schema, table, tt.wantSchema, tt.wantTable)
+			}
+		})
+	}
+}
+
+func TestConvertStructureData_Boolean(t *testing.T) {
+	t.Parallel()
+
+	runConvertStructureData(t, ColumnType{Name: booleanType}, []convertTest{
+		{name: "bool", value: true, want: true},
+		{name: "string", value: "false", want: false},
+		{name: "number", value: float64(1), want: true},
+		{name: "invalid number", value: float64(2), wantErr: true},
+		{name: "invalid string", value: "yes", wantErr: true},
+	})
+}
+
+func TestConvertStructureData_Bytes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		columnType ColumnType
+		want       any
+		wantErr    bool
+	}{
+		{name: "varchar", columnType: ColumnType{Name: varcharType}, want: "abc"},
+		{name: "clob", columnType: ColumnType{Name: clobType}, want: "abc"},
+		{name: "integer", columnType: ColumnType{Name: integerType}, wantErr: true},
+		{name: "double", columnType: ColumnType{Name: doubleType}, wantErr: true},
+		{name: "decimal", columnType: ColumnType{Name: decimalType, Length: 9, Scale: 2}, wantErr: true},
+		{name: "decfloat", columnType: ColumnType{Name: decimalFloat}, wantErr: true},
+		{name: "time", columnType: ColumnType{Name: timeType}, wantErr: true},
+		{name: "boolean", columnType: ColumnType{Name: booleanType}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			runConvertStructureData(t, tt.columnType, []convertTest{
+				{name: "bytes", value: []byte("abc"), want: tt.want, wantErr: tt.wantErr},
+			})
+		})
+	}
+}
+
+func TestConvertStructureData_Smallint(t *testing.T) {
+	t.Parallel()
+
+	runConvertStructureData(t, ColumnType{Name: smallintType}, []convertTest{
+		{name: "number", value: float64(32767), want: int64(32767)},
+		{name: "string", value: "-32768", want: int64(-32768)},
+		{name: "overflow", value: float64(32768), wantErr: true},
+		{name: "fraction", value: 1.5, wantErr: true},
+		{name: "whole json number", value: json.Number("-2.0"), want: int64(-2)},
+		{name: "json number overflow", value: json.Number("3.2768e4"), wantErr: true},
+	})
+}
+
+func TestConvertStructureData_Integer(t *testing.T) {
+	t.Parallel()
+
+	runConvertStructureData(t, ColumnType{Name: integerType}, []convertTest{
+		{name: "number", value: float64(2147483647), want: int64(2147483647)},
+		{name: "int", value: 42, want: int64(42)},
+		{name: "json number", value: json.Number("42"), want: int64(42)},
+		{name: "json number with fraction", value: json.Number("1.0"), want: int64(1)},
+		{name: "json number with exponent", value: json.Number("1e3"), want: int64(1000)},
+		{name: "json number with negative exponent", value: json.Number("4200e-2"), want: int64(42)},
+		{name: "fractional json number", value: json.Number("1.5"), wantErr: true},
+		{name: "small json number", value: json.Number("1e-400"), wantErr: true},
+		{name: "huge json number", value: json.Number("1e1000000000"), wantErr: true},
+		{name: "bool", value: true, want: int64(1)},
+		{name: "overflow", value: "2147483648", wantErr: true},
+		{name: "invalid string", value: "forty two", wantErr: true},
+	})
+}
+
+func TestConvertStructureData_Bigint(t *testing.T) {
+	t.Parallel()
+
+	runConvertStructureData(t, ColumnType{Name: bigintType}, []convertTest{
+		{name: "string", value: "9223372036854775807", want: int64(math.MaxInt64)},
+		{name: "uint64", value: uint64(math.MaxInt64), want: int64(math.MaxInt64)},
+		{name: "string overflow", value: "9223372036854775808", wantErr: true},
+		{name: "exact json number", value: json.Number("9007199254740993.0"), want: int64(9007199254740993)},
+		{name: "json number overflow", value: json.Number("9.223372036854775808e18"), wantErr: true},
+		{name: "number overflow", value: float64(math.MaxInt64), wantErr: true},
+		{name: "uint64 overflow", value: uint64(math.MaxUint64), wantErr: true},
+	})
+}
+
+func TestConvertStructureData_Real(t *testing.T) {
+	t.Parallel()
+
+	runConvertStructureData(t, ColumnType{Name: realType}, []convertTest{
+		{name: "number", value: 1.5, want: 1.5},
+		{name: "string", value: "1.5", want: 1.5},
+		{name: "overflow", value: math.MaxFloat64, wantErr: true},
+		{name: "invalid", value: true, wantErr: true},
+	})
+}
+
+func TestConvertStructureData_Double(t *testing.T) {
+	t.Parallel()
+
+	runConvertStructureData(t, ColumnType{Name: doubleType}, []convertTest{
+		{name: "number", value: math.MaxFloat64, want: math.MaxFloat64},
+		{name: "int", value: int32(7), want: float64(7)},
+		{name: "json number", value: json.Number("1e3"), want: float64(1000)},
+		{name: "invalid string", value: "one", wantErr: true},
+	})
+}
+
+func TestConvertStructureData_Decimal(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{decimalType, numericType} {
+		runConvertStructureData(t, ColumnType{Name: name, Length: 10, Scale: 2}, []convertTest{
+			{name: name + " number", value: 12.5, want: "12.50"},
+			{name: name + " json number", value: json.Number("12345678.91"), want: "12345678.91"},
+			{name: name + " string", value: " -0.5 ", want: "-0.50"},
+			{name: name + " int", value: 42, want: "42.00"},
+			{name: name + " precision overflow", value: json.Number("123456789.1"), wantErr: true},
+			{name: name + " scale overflow", value: "1.005", wantErr: true},
+			{name: name + " invalid string", value: "twelve", wantErr: true},
+			{name: name + " invalid", value: true, wantErr: true},
+		})
+	}
+
+	runConvertStructureData(t, ColumnType{Name: decimalType, Length: 31}, []convertTest{
+		{name: "big number", value: json.Number("1234567890123456789012345678901"),
+			want: "1234567890123456789012345678901"},
+	})
+}
+
+func TestConvertStructureData_DecFloat(t *testing.T) {
+	t.Parallel()
+
+	runConvertStructureData(t, ColumnType{Name: decimalFloat, Length: 16}, []convertTest{
+		{name: "json number", value: json.Number("0.1000000000000000000000000000000001"),
+			want: "0.1000000000000000000000000000000001"},
+		{name: "number", value: 0.1, want: "0.1"},
+		{name: "int64", value: int64(42), want: "42"},
+		{name: "int32", value: int32(42), want: "42"},
+		{name: "exponent", value: "1.5E+300", want: "1.5E+300"},
+		{name: "special value", value: "NaN", want: "NaN"},
+		{name: "precision overflow", value: json.Number("1.00000000000000000000000000000000001"), wantErr: true},
+		{name: "invalid string", value: "one", wantErr: true},
+		{name: "invalid", value: true, wantErr: true},
+	})
+
+	runConvertStructureData(t, ColumnType{Name: decimalFloat, Length: 8}, []convertTest{
+		{name: "decfloat16", value: "1234567890.123456", want: "1234567890.123456"},
+		{name: "decfloat16 precision overflow", value: "1234567890.1234567", wantErr: true},
+	})
+}
+
+func TestConvertStructureData_Character(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{charType, varcharType, longVarcharType, clobType} {
+		runConvertStructureData(t, ColumnType{Name: name, Length: 255}, []convertTest{
+			{name: name + " string", value: "test", want: "test"},
+			{name: name + " number", value: 1.5, want: "1.5"},
+			{name: name + " bool", value: true, want: "true"},
+			{name: name + " object", value: map[string]any{"a": 1}, want: `{"a":1}`},
+		})
+	}
+}
+
+func TestConvertStructureData_Graphic(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{graphicType, varGraphicType, longVarGraphicType, dbclobType} {
+		runConvertStructureData(t, ColumnType{Name: name, Length: 255}, []convertTest{
+			{name: name + " string", value: "тест", want: "тест"},
+			{name: name + " int", value: 42, want: "42"},
+		})
+	}
+}
+
+func TestConvertStructureData_XML(t *testing.T) {
+	t.Parallel()
+
+	runConvertStructureData(t, ColumnType{Name: xmlType}, []convertTest{
+		{name: "string", value: "<a>1</a>", want: XML("<a>1</a>")},
+		{name: "bytes", value: []byte("<?xml version=\"1.0\"?>\n<a/>"), want: XML("<?xml version=\"1.0\"?>\n<a/>")},
+		{
+			name:  "declared encoding",
+			value: `<?xml version="1.0" encoding="ISO-8859-1"?><a/>`,
+			want:  XML(`<?xml version="1.0" encoding="ISO-8859-1"?><a/>`),
+		},
+		{
+			name: "object",
+			value: map[string]any{
+				"@id":   json.Number("1"),
+				"name":  "A & B",
+				"tags":  []any{"x", "y"},
+				"empty": nil,
+				"geo":   map[string]any{"lat": 50.45, "#text": "Kyiv"},
+			},
+			want: XML(`<root id="1"><empty/><geo>Kyiv<lat>50.45</lat></geo>` +
+				`<name>A &amp; B</name><tags>x</tags><tags>y</tags></root>`),
+		},
+		{
+			name:  "array",
+			value: []any{1, []any{2, 3}},
+			want:  XML("<root><item>1</item><item><item>2</item><item>3</item></item></root>"),
+		},
+		{name: "number", value: 42, wantErr: true},
+		{name: "invalid", value: struct{}{}, wantErr: true},
+		{name: "invalid element name", value: map[string]any{"1st": 1}, wantErr: true},
+		{name: "invalid attribute value", value: map[string]any{"@a": []any{1}}, wantErr: true},
+		{name: "unclosed element", value: "<a><b></a>", wantErr: true},
+		{name: "no root element", value: "text", wantErr: true},
+		{name: "multiple root elements", value: "<a/><b/>", wantErr: true},
+		{name: "text after root element", value: "<a/>text", wantErr: true},
+		{name: "unescaped ampersand", value: "<a>A & B</a>", wantErr: true},
+	})
+}
+
+func TestConvertStructureData_XMLRootElement(t *testing.T) {
+	t.Parallel()
+
+	data := sdk.StructuredData{"col": map[string]any{"a": 1}}
+	columnTypes := map[string]ColumnType{"COL": {Name: xmlType}}
+
+	got, err := ConvertStructureData(context.Background(), columnTypes, data, Options{XMLRootElement: "user"})
+	if err != nil {
+		t.Fatalf("ConvertStructureData() error = %v", err)
+	}
+
+	if want := XML("<user><a>1</a></user>"); got["col"] != want {
+		t.Errorf("ConvertStructureData() = %v, want %v", got["col"], want)
+	}
+
+	_, err = ConvertStructureData(context.Background(), columnTypes, data, Options{XMLRootElement: "a b"})
+	if !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("ConvertStructureData() error = %v, want %v", err, ErrInvalidValue)
+	}
+}
+
+func TestTransformRow_XML(t *testing.T) {
+	t.Parallel()
+
+	runTransformRow(t, ColumnType{Name: xmlType}, []convertTest{
+		{name: "bytes", value: []byte("<a>text</a>"), want: "<a>text</a>"},
+		{name: "string", value: "<a>text</a>", want: "<a>text</a>"},
+		{name: "invalid", value: 42, wantErr: true},
+	})
+}
+
+func TestConvertStructureData_Binary(t *testing.T) {
+	t.Parallel()
+
+	for _, columnType := range []ColumnType{
+		{Name: binary}, {Name: varbinary}, {Name: blob}, {Name: rowID},
+		{Name: charType, ForBitData: true}, {Name: varcharType, ForBitData: true},
+	} {
+		runConvertStructureData(t, columnType, []convertTest{
+			{name: columnType.Name + " bytes", value: []byte{0x01, 0x02}, want: []byte{0x01, 0x02}},
+			{name: columnType.Name + " string", value: "test", want: []byte("test")},
+			{name: columnType.Name + " number", value: 1.5, wantErr: true},
+		})
+	}
+}
+
+func TestConvertStructureData_Time(t *testing.T) {
+	t.Parallel()
+
+	want := time.Date(2014, 11, 12, 11, 45, 26, 371000000, time.UTC)
+
+	for _, name := range []string{date, timeType, timeStamp} {
+		runConvertStructureData(t, ColumnType{Name: name}, []convertTest{
+			{name: name + " time", value: want, want: want},
+			{name: name + " string", value: "2014-11-12T11:45:26.371Z", want: want},
+			{name: name + " invalid string", value: "test", wantErr: true},
+		})
+	}
+}
+
+func TestTransformRow_Character(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{charType, varcharType, longVarcharType, clobType, graphicType,
+		varGraphicType, longVarGraphicType, dbclobType, xmlType} {
+		runTransformRow(t, ColumnType{Name: name}, []convertTest{
+			{name: name + " bytes", value: []byte("test"), want: "test"},
+			{name: name + " string", value: "test", want: "test"},
+			{name: name + " invalid", value: 42, wantErr: true},
+		})
+	}
+}
+
+func TestTransformRow_Decimal(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{decimalType, numericType, decimalFloat} {
+		runTransformRow(t, ColumnType{Name: name}, []convertTest{
+			{name: name + " bytes", value: []byte("12.50"), want: json.Number("12.50")},
+			{name: name + " string", value: "-1E+10", want: json.Number("-1E+10")},
+			{name: name + " float", value: 0.1, want: json.Number("0.1")},
+		})
+	}
+}
+
+func TestTransformRow_Boolean(t *testing.T) {
+	t.Parallel()
+
+	runTransformRow(t, ColumnType{Name: booleanType}, []convertTest{
+		{name: "bool", value: true, want: true},
+		{name: "int", value: int16(0), want: false},
+		{name: "bytes", value: []byte("1"), want: true},
+	})
+}
+
+func TestTransformRow_Integer(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{smallintType, integerType, bigintType} {
+		runTransformRow(t, ColumnType{Name: name}, []convertTest{
+			{name: name + " int16", value: int16(42), want: int64(42)},
+			{name: name + " int32", value: int32(42), want: int64(42)},
+			{name: name + " bytes", value: []byte("42"), want: int64(42)},
+		})
+	}
+}
+
+func TestTransformRow_Float(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{realType, doubleType} {
+		runTransformRow(t, ColumnType{Name: name}, []convertTest{
+			{name: name + " float32", value: float32(1.5), want: 1.5},
+			{name: name + " float64", value: 1.5, want: 1.5},
+			{name: name + " bytes", value: []byte("1.5"), want: 1.5},
+		})
+	}
+}
+
+func TestColumnType_IsString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		columnType ColumnType
+		want       bool
+	}{
+		{columnType: ColumnType{Name: varcharType}, want: true},
+		{columnType: ColumnType{Name: varcharType, ForBitData: true}, want: false},
+		{columnType: ColumnType{Name: dbclobType}, want: true},
+		{columnType: ColumnType{Name: xmlType}, want: true},
+		{columnType: ColumnType{Name: integerType}, want: false},
+		{columnType: ColumnType{Name: blob}, want: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.columnType.Name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.columnType.IsString(); got != tt.want {
+				t.Errorf("IsString() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColumnType_IsLOB(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		columnType ColumnType
+		want       bool
+	}{
+		{columnType: ColumnType{Name: clobType}, want: true},
+		{columnType: ColumnType{Name: dbclobType}, want: true},
+		{columnType: ColumnType{Name: blob}, want: true},
+		{columnType: ColumnType{Name: varcharType}, want: false},
+		{columnType: ColumnType{Name: xmlType}, want: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.columnType.Name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.columnType.IsLOB(); got != tt.want {
+				t.Errorf("IsLOB() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertStructureData_TimeOptions(t *testing.T) {
+	t.Parallel()
+
+	kyiv, err := time.LoadLocation("Europe/Kyiv")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		columnType ColumnType
+		value      any
+		opts       Options
+		want       any
+		wantErr    bool
+	}{
+		{
+			name:       "string without zone in location",
+			columnType: ColumnType{Name: timeStamp, Scale: 6},
+			value:      "2022-10-01 12:00:00",
+			opts:       Options{Location: kyiv},
+			want:       time.Date(2022, 10, 1, 12, 0, 0, 0, kyiv),
+		},
+		{
+			name:       "string with zone converted to location",
+			columnType: ColumnType{Name: timeStamp, Scale: 6},
+			value:      "2022-10-01T12:00:00Z",
+			opts:       Options{Location: kyiv},
+			want:       time.Date(2022, 10, 1, 15, 0, 0, 0, kyiv),
+		},
+		{
+			name:       "custom layout",
+			columnType: ColumnType{Name: date},
+			value:      "01.10.2022",
+			opts:       Options{TimeLayouts: []string{"02.01.2006"}},
+			want:       time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "epoch milliseconds by default",
+			columnType: ColumnType{Name: timeStamp, Scale: 6},
+			value:      json.Number("1664625600123"),
+			want:       time.Date(2022, 10, 1, 12, 0, 0, 123000000, time.UTC),
+		},
+		{
+			name:       "epoch seconds",
+			columnType: ColumnType{Name: timeStamp, Scale: 6},
+			value:      json.Number("1664625600.5"),
+			opts:       Options{EpochUnit: time.Second},
+			want:       time.Date(2022, 10, 1, 12, 0, 0, 500000000, time.UTC),
+		},
+		{
+			name:       "epoch microseconds",
+			columnType: ColumnType{Name: timeStamp, Scale: 6},
+			value:      int64(1664625600000001),
+			opts:       Options{EpochUnit: time.Microsecond},
+			want:       time.Date(2022, 10, 1, 12, 0, 0, 1000, time.UTC),
+		},
+		{
+			name:       "timestamp(12) string",
+			columnType: ColumnType{Name: timeStamp, Scale: 12},
+			value:      "2022-10-01-12.00.00.123456789012",
+			want:       "2022-10-01-12.00.00.123456789012",
+		},
+		{
+			name:       "timestamp(12) time",
+			columnType: ColumnType{Name: timeStamp, Scale: 12},
+			value:      time.Date(2022, 10, 1, 12, 0, 0, 5, time.UTC),
+			want:       "2022-10-01-12.00.00.000000005000",
+		},
+		{
+			name:       "invalid value",
+			columnType: ColumnType{Name: timeStamp},
+			value:      true,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ConvertStructureData(context.Background(),
+				map[string]ColumnType{"COL": tt.columnType}, sdk.StructuredData{"col": tt.value}, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ConvertStructureData() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if want, ok := tt.want.(time.Time); ok {
+				gotTime, ok := got["col"].(time.Time)
+				if !ok || !gotTime.Equal(want) || gotTime.Location() != want.Location() {
+					t.Errorf("ConvertStructureData() = %v, want %v", got["col"], want)
+				}
+
+				return
+			}
+
+			if !reflect.DeepEqual(got["col"], tt.want) {
+				t.Errorf("ConvertStructureData() = %v, want %v", got["col"], tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertStructureData_Overflow(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		columnType ColumnType
+		value      any
+		policy     OverflowPolicy
+		want       any
+		wantErr    bool
+	}{
+		{
+			name:       "varchar fits",
+			columnType: ColumnType{Name: varcharType, Length: 4},
+			value:      "test",
+			policy:     OverflowFail,
+			want:       "test",
+		},
+		{
+			name:       "varchar fail",
+			columnType: ColumnType{Name: varcharType, Length: 4},
+			value:      "tests",
+			policy:     OverflowFail,
+			wantErr:    true,
+		},
+		{
+			name:       "varchar length in bytes",
+			columnType: ColumnType{Name: varcharType, Length: 4},
+			value:      "тест",
+			policy:     OverflowTruncate,
+			want:       "те",
+		},
+		{
+			name:       "vargraphic length in code units",
+			columnType: ColumnType{Name: varGraphicType, Length: 4},
+			value:      "тесты",
+			policy:     OverflowTruncate,
+			want:       "тест",
+		},
+		{
+			name:       "vargraphic surrogate pairs",
+			columnType: ColumnType{Name: varGraphicType, Length: 3},
+			value:      "a😀b",
+			policy:     OverflowTruncate,
+			want:       "a😀",
+		},
+		{
+			name:       "varchar null",
+			columnType: ColumnType{Name: varcharType, Length: 2},
+			value:      "test",
+			policy:     OverflowNull,
+			want:       nil,
+		},
+		{
+			name:       "varbinary truncate",
+			columnType: ColumnType{Name: varbinary, Length: 2},
+			value:      []byte{0x01, 0x02, 0x03},
+			policy:     OverflowTruncate,
+			want:       []byte{0x01, 0x02},
+		},
+		{
+			name:       "smallint truncate",
+			columnType: ColumnType{Name: smallintType},
+			value:      json.Number("-40000"),
+			policy:     OverflowTruncate,
+			want:       int64(math.MinInt16),
+		},
+		{
+			name:       "integer null",
+			columnType: ColumnType{Name: integerType},
+			value:      float64(math.MaxInt32 + 1),
+			policy:     OverflowNull,
+			want:       nil,
+		},
+		{
+			name:       "integer invalid is not overflow",
+			columnType: ColumnType{Name: integerType},
+			value:      1.5,
+			policy:     OverflowNull,
+			wantErr:    true,
+		},
+		{
+			name:       "real truncate",
+			columnType: ColumnType{Name: realType},
+			value:      -math.MaxFloat64,
+			policy:     OverflowTruncate,
+			want:       -math.MaxFloat32,
+		},
+		{
+			name:       "decimal scale truncate",
+			columnType: ColumnType{Name: decimalType, Length: 5, Scale: 2},
+			value:      json.Number("-1.239"),
+			policy:     OverflowTruncate,
+			want:       "-1.23",
+		},
+		{
+			name:       "decimal precision truncate",
+			columnType: ColumnType{Name: decimalType, Length: 5, Scale: 2},
+			value:      json.Number("12345.6"),
+			policy:     OverflowTruncate,
+			want:       "999.99",
+		},
+		{
+			name:       "decimal fail",
+			columnType: ColumnType{Name: decimalType, Length: 5, Scale: 2},
+			value:      json.Number("1000"),
+			policy:     OverflowFail,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ConvertStructureData(context.Background(),
+				map[string]ColumnType{"COL": tt.columnType}, sdk.StructuredData{"col": tt.value},
+				Options{ColumnOverflow: map[string]OverflowPolicy{"COL": tt.policy}})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ConvertStructureData() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && !reflect.DeepEqual(got["col"], tt.want) {
+				t.Errorf("ConvertStructureData() = %v (%T), want %v (%T)", got["col"], got["col"], tt.want, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertStructureData_BinaryEncoding(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		encoding BinaryEncoding
+		value    any
+		want     []byte
+		wantErr  bool
+	}{
+		{name: "raw", encoding: BinaryRaw, value: "AQI=", want: []byte("AQI=")},
+		{name: "base64", encoding: BinaryBase64, value: "AQI=", want: []byte{0x01, 0x02}},
+		{name: "invalid base64", encoding: BinaryBase64, value: "AQI", wantErr: true},
+		{name: "hex", encoding: BinaryHex, value: "0102", want: []byte{0x01, 0x02}},
+		{name: "invalid hex", encoding: BinaryHex, value: "xyz", wantErr: true},
+		{name: "bytes are not decoded", encoding: BinaryHex, value: []byte("0102"), want: []byte("0102")},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ConvertStructureData(context.Background(),
+				map[string]ColumnType{"COL": {Name: blob}}, sdk.StructuredData{"col": tt.value},
+				Options{BinaryEncoding: tt.encoding})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ConvertStructureData() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && !reflect.DeepEqual(got["col"], tt.want) {
+				t.Errorf("ConvertStructureData() = %v, want %v", got["col"], tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistry_Lookup(t *testing.T) {
+	t.Parallel()
+
+	money := ConverterFuncs{
+		EncodeFunc: func(_ string, value any, _ ColumnType, _ Options) (any, error) {
+			return fmt.Sprintf("$%v", value), nil
+		},
+	}
+
+	registry := NewRegistry()
+	registry.Register("money", money)
+
+	tests := []struct {
+		name       string
+		columnType ColumnType
+		value      any
+		want       any
+		wantErr    bool
+	}{
+		{
+			name:       "built-in type",
+			columnType: ColumnType{Name: integerType},
+			value:      "12",
+			want:       int64(12),
+		},
+		{
+			name:       "registered distinct type",
+			columnType: ColumnType{Name: decimalType, UserType: "MONEY", Length: 9, Scale: 2},
+			value:      "12",
+			want:       "$12",
+		},
+		{
+			name:       "distinct type uses its source type",
+			columnType: ColumnType{Name: integerType, UserType: "AGE"},
+			value:      "twelve",
+			wantErr:    true,
+		},
+		{
+			name:       "for bit data",
+			columnType: ColumnType{Name: varcharType, ForBitData: true},
+			value:      "ab",
+			want:       []byte("ab"),
+		},
+		{
+			name:       "unknown type",
+			columnType: ColumnType{Name: "POINT"},
+			value:      map[string]any{"x": 1},
+			want:       `{"x":1}`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ConvertStructureData(context.Background(),
+				map[string]ColumnType{"COL": tt.columnType}, sdk.StructuredData{"col": tt.value},
+				Options{Registry: registry})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ConvertStructureData() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && !reflect.DeepEqual(got["col"], tt.want) {
+				t.Errorf("ConvertStructureData() = %v (%T), want %v (%T)", got["col"], got["col"], tt.want, tt.want)
+			}
+		})
+	}
+}
+
+func TestConverterFuncs_nil(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	registry.Register(integerType, ConverterFuncs{})
+
+	got, err := TransformRow(context.Background(), map[string]any{"COL": "12"},
+		map[string]ColumnType{"COL": {Name: integerType}}, Options{Registry: registry})
+	if err != nil {
+		t.Fatalf("TransformRow() error = %v", err)
+	}
+
+	if got["COL"] != "12" {
+		t.Errorf("TransformRow() = %v, want %v", got["COL"], "12")
+	}
+}
@@ -0,0 +1,290 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coltypes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// writeBool converts the value to a bool. Strings are parsed, numbers must be 0 or 1.
+func writeBool(name string, value any) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case json.Number:
+		return writeBool(name, v.String())
+	case string:
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return false, invalidValueErr(name, value)
+		}
+
+		return b, nil
+	}
+
+	f, ok := toFloat(value)
+	if !ok || (f != 0 && f != 1) {
+		return false, invalidValueErr(name, value)
+	}
+
+	return f == 1, nil
+}
+
+// writeInt converts the value to an int64 within the [minValue, maxValue] range.
+// Strings are parsed, fractional numbers are rejected, booleans are converted to 1 and 0.
+func writeInt(name string, value any, minValue, maxValue int64) (int64, error) {
+	var i int64
+
+	switch v := value.(type) {
+	case bool:
+		if v {
+			i = 1
+		}
+	case string:
+		parsed, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		if err != nil {
+			if errors.Is(err, strconv.ErrRange) {
+				return 0, valueOutOfRangeErr(name, value)
+			}
+
+			return 0, invalidValueErr(name, value)
+		}
+
+		i = parsed
+	case json.Number:
+		parsed, err := parseIntNumber(v)
+		if err != nil {
+			if errors.Is(err, strconv.ErrRange) {
+				return 0, valueOutOfRangeErr(name, value)
+			}
+
+			return 0, invalidValueErr(name, value)
+		}
+
+		i = parsed
+	case float32, float64:
+		f, _ := toFloat(v)
+		if f != math.Trunc(f) {
+			return 0, invalidValueErr(name, value)
+		}
+
+		// the maximum values are one less than the negated minimum values, which are exact floats.
+		if f < float64(minValue) || f >= -float64(minValue) {
+			return 0, valueOutOfRangeErr(name, value)
+		}
+
+		i = int64(f)
+	default:
+		rv := reflect.ValueOf(value)
+
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			i = rv.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if rv.Uint() > math.MaxInt64 {
+				return 0, valueOutOfRangeErr(name, value)
+			}
+
+			i = int64(rv.Uint())
+		default:
+			return 0, invalidValueErr(name, value)
+		}
+	}
+
+	if i < minValue || i > maxValue {
+		return 0, valueOutOfRangeErr(name, value)
+	}
+
+	return i, nil
+}
+
+// parseIntNumber parses the JSON number as an int64. Numbers with fractions or exponents are accepted
+// if they're whole, e.g. 1.0 or 1e3, the same as floats, but they're parsed exactly.
+func parseIntNumber(number json.Number) (int64, error) {
+	if i, err := strconv.ParseInt(number.String(), 10, 64); err == nil || errors.Is(err, strconv.ErrRange) {
+		return i, err
+	}
+
+	f, err := strconv.ParseFloat(number.String(), 64)
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
+		return 0, err
+	}
+
+	// numbers far out of the range aren't parsed exactly, since large exponents are expensive.
+	if math.Abs(f) > -2*float64(math.MinInt64) {
+		return 0, strconv.ErrRange
+	}
+
+	r, ok := new(big.Rat).SetString(number.String())
+	if !ok || !r.IsInt() {
+		return 0, strconv.ErrSyntax
+	}
+
+	if !r.Num().IsInt64() {
+		return 0, strconv.ErrRange
+	}
+
+	return r.Num().Int64(), nil
+}
+
+// writeFloat converts the value to a float64 whose absolute value doesn't exceed the maxValue.
+// Strings are parsed.
+func writeFloat(name string, value any, maxValue float64) (float64, error) {
+	var f float64
+
+	switch v := value.(type) {
+	case string:
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, invalidValueErr(name, value)
+		}
+
+		f = parsed
+	case json.Number:
+		return writeFloat(name, v.String(), maxValue)
+	default:
+		var ok bool
+		if f, ok = toFloat(value); !ok {
+			return 0, invalidValueErr(name, value)
+		}
+	}
+
+	if math.Abs(f) > maxValue {
+		return 0, valueOutOfRangeErr(name, value)
+	}
+
+	return f, nil
+}
+
+// writeString converts the value to a string. Times are formatted according to RFC 3339.
+func writeString(name string, value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case json.Number:
+		return v.String(), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case fmt.Stringer:
+		return v.String(), nil
+	}
+
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fmt.Sprint(value), nil
+	default:
+		return "", invalidValueErr(name, value)
+	}
+}
+
+// writeBytes converts the value to a byte slice. Strings are decoded according to the encoding,
+// maps and slices are written as JSON.
+func writeBytes(name string, value any, encoding BinaryEncoding) ([]byte, error) {
+	switch v := value.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return decodeBinary(name, v, encoding)
+	case json.Number:
+		return []byte(v.String()), nil
+	}
+
+	switch reflect.TypeOf(value).Kind() {
+	case reflect.Map, reflect.Slice:
+		bs, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("marshal: %w", err)
+		}
+
+		return bs, nil
+	default:
+		return nil, invalidValueErr(name, value)
+	}
+}
+
+// readString converts the value read from the database to a string.
+func readString(name string, value any) (string, error) {
+	switch v := value.(type) {
+	case []byte:
+		return string(v), nil
+	case string:
+		return v, nil
+	default:
+		return "", convertValueToBytesErr(name)
+	}
+}
+
+// readBool converts the value read from the database to a bool.
+func readBool(name string, value any) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case []byte:
+		return writeBool(name, string(v))
+	default:
+		return writeBool(name, value)
+	}
+}
+
+// readInt converts the value read from the database to an int64.
+func readInt(name string, value any) (int64, error) {
+	if v, ok := value.([]byte); ok {
+		value = string(v)
+	}
+
+	return writeInt(name, value, math.MinInt64, math.MaxInt64)
+}
+
+// readFloat converts the value read from the database to a float64.
+func readFloat(name string, value any) (float64, error) {
+	if v, ok := value.([]byte); ok {
+		value = string(v)
+	}
+
+	return writeFloat(name, value, math.MaxFloat64)
+}
+
+// toFloat converts a value of a numeric type to a float64.
+func toFloat(value any) (float64, bool) {
+	rv := reflect.ValueOf(value)
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	default:
+		return 0, false
+	}
+}
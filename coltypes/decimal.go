@@ -0,0 +1,161 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coltypes
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	// decFloat16Length is a length of DECFLOAT(16) columns in bytes, other DECFLOAT columns are DECFLOAT(34).
+	decFloat16Length = 8
+
+	decFloat16Precision = 16
+	decFloat34Precision = 34
+)
+
+// decFloatSpecialValues contains special values of DECFLOAT columns in lower case.
+var decFloatSpecialValues = map[string]struct{}{
+	"nan": {}, "-nan": {}, "snan": {}, "-snan": {},
+	"infinity": {}, "-infinity": {}, "inf": {}, "-inf": {},
+}
+
+// writeDecimal converts the value to an exact decimal string with the column's scale.
+// It fails if the value has more integer digits than the column's precision allows,
+// or more fractional digits than the column's scale.
+func writeDecimal(name string, value any, columnType ColumnType) (string, error) {
+	number, err := decimalString(name, value)
+	if err != nil {
+		return "", err
+	}
+
+	rat, ok := new(big.Rat).SetString(number)
+	if !ok {
+		return "", invalidValueErr(name, value)
+	}
+
+	// precision is unknown, so the value is passed as it is.
+	if columnType.Length == 0 {
+		return number, nil
+	}
+
+	scaled := rat.FloatString(columnType.Scale)
+
+	if exact, _ := new(big.Rat).SetString(scaled); exact.Cmp(rat) != 0 {
+		return "", fmt.Errorf("%w: scale %d", valueOutOfRangeErr(name, value), columnType.Scale)
+	}
+
+	if integerDigits(rat) > columnType.Length-columnType.Scale {
+		return "", fmt.Errorf("%w: precision %d", valueOutOfRangeErr(name, value), columnType.Length)
+	}
+
+	return scaled, nil
+}
+
+// writeDecFloat converts the value to an exact decimal string. It fails if the value has
+// more significant digits than the column's precision. Special values like NaN and Infinity are allowed.
+func writeDecFloat(name string, value any, columnType ColumnType) (string, error) {
+	number, err := decimalString(name, value)
+	if err != nil {
+		return "", fmt.Errorf("%w: %q: %v", ErrConvertDecFloat, name, value)
+	}
+
+	if _, ok := decFloatSpecialValues[strings.ToLower(number)]; ok {
+		return number, nil
+	}
+
+	if _, ok := new(big.Rat).SetString(number); !ok {
+		return "", fmt.Errorf("%w: %q: %v", ErrConvertDecFloat, name, value)
+	}
+
+	precision := decFloat34Precision
+	if columnType.Length == decFloat16Length {
+		precision = decFloat16Precision
+	}
+
+	if significantDigits(number) > precision {
+		return "", fmt.Errorf("%w: precision %d", valueOutOfRangeErr(name, value), precision)
+	}
+
+	return number, nil
+}
+
+// readDecimal converts the decimal value read from the database to an exact json.Number.
+func readDecimal(name string, value any) (json.Number, error) {
+	switch v := value.(type) {
+	case float32:
+		return json.Number(strconv.FormatFloat(float64(v), 'g', -1, 32)), nil
+	case float64:
+		return json.Number(strconv.FormatFloat(v, 'g', -1, 64)), nil
+	}
+
+	str, err := readString(name, value)
+	if err != nil {
+		return "", err
+	}
+
+	return json.Number(strings.TrimSpace(str)), nil
+}
+
+// decimalString returns a string representation of the numeric value that keeps all its digits.
+func decimalString(name string, value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v), nil
+	case json.Number:
+		return v.String(), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	}
+
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fmt.Sprint(value), nil
+	default:
+		return "", invalidValueErr(name, value)
+	}
+}
+
+// integerDigits returns a number of digits of the integer part of the number, zero has no integer digits.
+func integerDigits(rat *big.Rat) int {
+	integer := new(big.Int).Quo(rat.Num(), rat.Denom())
+	if integer.Sign() == 0 {
+		return 0
+	}
+
+	return len(integer.Abs(integer).Text(10))
+}
+
+// significantDigits returns a number of significant digits of the decimal string,
+// leading and trailing zeros are not significant.
+func significantDigits(number string) int {
+	if i := strings.IndexAny(number, "eE"); i >= 0 {
+		number = number[:i]
+	}
+
+	number = strings.TrimLeft(number, "+-")
+	number = strings.Replace(number, ".", "", 1)
+	number = strings.Trim(number, "0")
+
+	return len(number)
+}
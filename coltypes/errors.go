@@ -24,9 +24,30 @@ var (
 	ErrValueIsNotAString         = errors.New("value is not a string")
 	ErrConvertDecFloat           = errors.New("cannot convert DECFLOAT")
 	ErrInvalidTimeLayout         = errors.New("invalid time layout")
+	ErrInvalidValue              = errors.New("invalid value for the column type")
+	ErrValueOutOfRange           = errors.New("value is out of the column type range")
+	ErrMalformedXML              = errors.New("malformed XML document")
+
+	errTextOutsideRoot = errors.New("text outside of the root element")
+	errRootElements    = errors.New("document must have exactly one root element, got")
 )
 
 // convertValueToBytesErr returns the formatted ErrCannotConvertValueToBytes error.
 func convertValueToBytesErr(name string) error {
 	return fmt.Errorf("%w: %q", ErrCannotConvertValueToBytes, name)
 }
+
+// invalidValueErr returns the formatted ErrInvalidValue error.
+func invalidValueErr(name string, value any) error {
+	return fmt.Errorf("%w: %q: %v (%T)", ErrInvalidValue, name, value, value)
+}
+
+// valueOutOfRangeErr returns the formatted ErrValueOutOfRange error.
+func valueOutOfRangeErr(name string, value any) error {
+	return fmt.Errorf("%w: %q: %v", ErrValueOutOfRange, name, value)
+}
+
+// malformedXMLErr returns the formatted ErrMalformedXML error.
+func malformedXMLErr(name string, err error) error {
+	return fmt.Errorf("%w: %q: %v", ErrMalformedXML, name, err)
+}
@@ -0,0 +1,90 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coltypes
+
+import (
+	"strings"
+	"time"
+)
+
+// Options configures conversions of values.
+type Options struct {
+	// Location is a time zone used to interpret and render times, DB2 times have no zones. Default is UTC.
+	Location *time.Location
+	// TimeLayouts are layouts tried before the default ones when times are parsed from strings.
+	TimeLayouts []string
+	// EpochUnit is a unit of times represented by numbers since the Unix epoch. Default is milliseconds.
+	EpochUnit time.Duration
+	// Overflow is a default policy for values that don't fit their columns. Default is OverflowFail.
+	Overflow OverflowPolicy
+	// ColumnOverflow maps uppercased column names to their overflow policies, overriding the default one.
+	ColumnOverflow map[string]OverflowPolicy
+	// BinaryEncoding defines how values of binary columns are represented as strings. Default is BinaryRaw.
+	BinaryEncoding BinaryEncoding
+	// XMLRootElement is a name of the root element of XML documents serialized from structured data.
+	// Default is DefaultXMLRootElement.
+	XMLRootElement string
+	// Registry contains converters of the types. Default is the DefaultRegistry.
+	Registry *Registry
+}
+
+// registry returns the registry of the options or the DefaultRegistry, if it's not set.
+func (o Options) registry() *Registry {
+	if o.Registry == nil {
+		return DefaultRegistry
+	}
+
+	return o.Registry
+}
+
+// xmlRootElement returns the XML root element of the options or DefaultXMLRootElement, if it's not set.
+func (o Options) xmlRootElement() string {
+	if o.XMLRootElement == "" {
+		return DefaultXMLRootElement
+	}
+
+	return o.XMLRootElement
+}
+
+// location returns the location of the options or UTC, if it's not set.
+func (o Options) location() *time.Location {
+	if o.Location == nil {
+		return time.UTC
+	}
+
+	return o.Location
+}
+
+// epochUnit returns the epoch unit of the options or milliseconds, if it's not set.
+func (o Options) epochUnit() time.Duration {
+	if o.EpochUnit == 0 {
+		return time.Millisecond
+	}
+
+	return o.EpochUnit
+}
+
+// overflowPolicy returns the policy of the column, the default policy of the options, or OverflowFail.
+func (o Options) overflowPolicy(column string) OverflowPolicy {
+	if policy, ok := o.ColumnOverflow[strings.ToUpper(column)]; ok {
+		return policy
+	}
+
+	if o.Overflow == "" {
+		return OverflowFail
+	}
+
+	return o.Overflow
+}
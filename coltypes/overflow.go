@@ -0,0 +1,237 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coltypes
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	sdk "github.com/conduitio/conduit-connector-sdk"
+)
+
+// OverflowPolicy defines what to do with values that don't fit their columns.
+type OverflowPolicy string
+
+const (
+	// OverflowFail fails the conversion with an error naming the column.
+	OverflowFail OverflowPolicy = "fail"
+	// OverflowTruncate truncates strings and binary values to the column's length,
+	// and clamps numbers to the column's range and scale.
+	OverflowTruncate OverflowPolicy = "truncate"
+	// OverflowNull replaces the values with NULLs.
+	OverflowNull OverflowPolicy = "null"
+)
+
+// intRange returns the range of the integer type.
+func intRange(typeName string) (int64, int64) {
+	switch typeName {
+	case smallintType:
+		return math.MinInt16, math.MaxInt16
+	case integerType:
+		return math.MinInt32, math.MaxInt32
+	default:
+		return math.MinInt64, math.MaxInt64
+	}
+}
+
+// floatRange returns the maximum absolute value of the floating-point type.
+func floatRange(typeName string) float64 {
+	if typeName == realType {
+		return math.MaxFloat32
+	}
+
+	return math.MaxFloat64
+}
+
+// checkLength returns ErrValueOutOfRange if the converted value is longer than the column.
+// Lengths of graphic types are measured in UTF-16 code units, lengths of other types in bytes.
+func checkLength(name string, value any, columnType ColumnType) error {
+	if columnType.Length == 0 {
+		return nil
+	}
+
+	var length int
+
+	switch v := value.(type) {
+	case string:
+		if !columnType.IsString() {
+			return nil
+		}
+
+		length = stringLength(v, columnType)
+	case []byte:
+		if !columnType.IsBinary() {
+			return nil
+		}
+
+		length = len(v)
+	default:
+		return nil
+	}
+
+	if length > columnType.Length {
+		return fmt.Errorf("%w: length %d exceeds %d", valueOutOfRangeErr(name, value), length, columnType.Length)
+	}
+
+	return nil
+}
+
+// handleOverflow applies the policy to the value that doesn't fit the column.
+// The original value is used to clamp numbers, the converted one is used to truncate strings and binary values.
+func handleOverflow(
+	ctx context.Context,
+	name string,
+	original, converted any,
+	columnType ColumnType,
+	policy OverflowPolicy,
+	overflowErr error,
+) (any, error) {
+	switch policy {
+	case OverflowNull:
+		sdk.Logger(ctx).Debug().Str("column", name).Msgf("the value is set to NULL: %v", overflowErr)
+
+		return nil, nil
+
+	case OverflowTruncate:
+		truncated, err := truncateValue(name, original, converted, columnType)
+		if err != nil {
+			return nil, err
+		}
+
+		sdk.Logger(ctx).Debug().Str("column", name).Msgf("the value is truncated: %v", overflowErr)
+
+		return truncated, nil
+
+	default:
+		return nil, overflowErr
+	}
+}
+
+// truncateValue makes the value fit the column.
+func truncateValue(name string, original, converted any, columnType ColumnType) (any, error) {
+	switch v := converted.(type) {
+	case string:
+		if columnType.IsString() {
+			return truncateString(v, columnType), nil
+		}
+	case []byte:
+		if columnType.IsBinary() {
+			return v[:columnType.Length], nil
+		}
+	}
+
+	number, err := decimalString(name, original)
+	if err != nil {
+		return nil, err
+	}
+
+	switch columnType.Name {
+	case smallintType, integerType, bigintType:
+		minValue, maxValue := intRange(columnType.Name)
+
+		rat, ok := new(big.Rat).SetString(number)
+		if !ok {
+			return nil, invalidValueErr(name, original)
+		}
+
+		if rat.Sign() < 0 {
+			return minValue, nil
+		}
+
+		return maxValue, nil
+
+	case realType, doubleType:
+		f, _ := strconv.ParseFloat(number, 64)
+
+		return math.Copysign(floatRange(columnType.Name), f), nil
+
+	case decimalType, numericType:
+		return truncateDecimal(name, number, columnType)
+
+	// DB2 rounds DECFLOAT values to the column's precision.
+	case decimalFloat:
+		return number, nil
+
+	default:
+		return nil, invalidValueErr(name, original)
+	}
+}
+
+// truncateDecimal drops fractional digits of the number beyond the column's scale
+// and clamps it to the column's precision.
+func truncateDecimal(name, number string, columnType ColumnType) (string, error) {
+	rat, ok := new(big.Rat).SetString(number)
+	if !ok {
+		return "", invalidValueErr(name, number)
+	}
+
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(columnType.Scale)), nil)
+
+	// truncate towards zero to the column's scale.
+	units := new(big.Int).Quo(new(big.Int).Mul(rat.Num(), scale), rat.Denom())
+
+	limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(columnType.Length)), nil)
+	limit.Sub(limit, big.NewInt(1))
+
+	if units.CmpAbs(limit) > 0 {
+		units.Mul(limit, big.NewInt(int64(units.Sign())))
+	}
+
+	return new(big.Rat).SetFrac(units, scale).FloatString(columnType.Scale), nil
+}
+
+// stringLength returns a length of the string in the code units of the column.
+func stringLength(value string, columnType ColumnType) int {
+	if isGraphic(columnType) {
+		return len(utf16.Encode([]rune(value)))
+	}
+
+	return len(value)
+}
+
+// truncateString truncates the string to the column's length without splitting characters.
+func truncateString(value string, columnType ColumnType) string {
+	length := 0
+
+	for i, r := range value {
+		size := utf8.RuneLen(r)
+		if isGraphic(columnType) {
+			size = len(utf16.Encode([]rune{r}))
+		}
+
+		if length+size > columnType.Length {
+			return value[:i]
+		}
+
+		length += size
+	}
+
+	return value
+}
+
+// isGraphic reports whether the column is of a graphic type, whose lengths are measured in UTF-16 code units.
+func isGraphic(columnType ColumnType) bool {
+	switch columnType.Name {
+	case graphicType, varGraphicType, longVarGraphicType, dbclobType:
+		return true
+	default:
+		return false
+	}
+}
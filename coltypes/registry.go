@@ -0,0 +1,240 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coltypes
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Converter converts values of a DB2 type in both directions.
+type Converter interface {
+	// Encode converts the record's value to a value bound to a statement parameter of the column.
+	Encode(name string, value any, columnType ColumnType, opts Options) (any, error)
+	// Decode converts the value read from the column to the record's value.
+	Decode(name string, value any, columnType ColumnType, opts Options) (any, error)
+}
+
+// ConvertFunc converts a single value of the column.
+type ConvertFunc func(name string, value any, columnType ColumnType, opts Options) (any, error)
+
+// ConverterFuncs implements the Converter interface with functions, nil functions return values as they are.
+type ConverterFuncs struct {
+	EncodeFunc ConvertFunc
+	DecodeFunc ConvertFunc
+}
+
+// Encode converts the record's value with the EncodeFunc.
+func (c ConverterFuncs) Encode(name string, value any, columnType ColumnType, opts Options) (any, error) {
+	if c.EncodeFunc == nil {
+		return value, nil
+	}
+
+	return c.EncodeFunc(name, value, columnType, opts)
+}
+
+// Decode converts the column's value with the DecodeFunc.
+func (c ConverterFuncs) Decode(name string, value any, columnType ColumnType, opts Options) (any, error) {
+	if c.DecodeFunc == nil {
+		return value, nil
+	}
+
+	return c.DecodeFunc(name, value, columnType, opts)
+}
+
+// Registry maps DB2 type names to their converters. It's safe for concurrent use.
+type Registry struct {
+	mu         sync.RWMutex
+	converters map[string]Converter
+}
+
+// DefaultRegistry is a registry used when the options have no registry.
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry creates a registry with the built-in converters of the DB2 types.
+func NewRegistry() *Registry {
+	registry := &Registry{converters: make(map[string]Converter)}
+
+	for _, name := range []string{charType, varcharType, longVarcharType, clobType,
+		graphicType, varGraphicType, longVarGraphicType, dbclobType} {
+		registry.Register(name, stringConverter)
+	}
+
+	for _, name := range []string{binary, varbinary, blob, rowID} {
+		registry.Register(name, binaryConverter)
+	}
+
+	for _, name := range []string{date, timeType, timeStamp} {
+		registry.Register(name, timeConverter)
+	}
+
+	for _, name := range []string{smallintType, integerType, bigintType} {
+		registry.Register(name, intConverter)
+	}
+
+	for _, name := range []string{realType, doubleType} {
+		registry.Register(name, floatConverter)
+	}
+
+	registry.Register(xmlType, xmlConverter)
+	registry.Register(decimalType, decimalConverter)
+	registry.Register(numericType, decimalConverter)
+	registry.Register(decimalFloat, decFloatConverter)
+	registry.Register(booleanType, boolConverter)
+
+	return registry
+}
+
+// Register registers the converter of the type, replacing the previous one.
+// Names of distinct types are the names they're created with, e.g. "MONEY" for CREATE TYPE MONEY AS DECIMAL(9,2).
+func (r *Registry) Register(typeName string, converter Converter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.converters[strings.ToUpper(typeName)] = converter
+}
+
+// Lookup returns the converter of the column type. Distinct types without their own converters
+// use the converters of their source types. Types without converters are converted by the default converter,
+// which writes maps and slices as JSON strings and passes other values as they are.
+// FOR BIT DATA character types use the converter of the VARBINARY type.
+func (r *Registry) Lookup(columnType ColumnType) Converter {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if columnType.UserType != "" {
+		if converter, ok := r.converters[columnType.UserType]; ok {
+			return converter
+		}
+	}
+
+	name := columnType.Name
+	// FOR BIT DATA character types store binary data.
+	if columnType.ForBitData {
+		name = varbinary
+	}
+
+	if converter, ok := r.converters[name]; ok {
+		return converter
+	}
+
+	return defaultConverter
+}
+
+// Register registers the converter of the type in the DefaultRegistry.
+func Register(typeName string, converter Converter) {
+	DefaultRegistry.Register(typeName, converter)
+}
+
+// built-in converters.
+var (
+	defaultConverter Converter = ConverterFuncs{
+		EncodeFunc: withJSON(func(_ string, value any, _ ColumnType, _ Options) (any, error) {
+			return value, nil
+		}),
+	}
+
+	stringConverter Converter = ConverterFuncs{
+		EncodeFunc: withJSON(func(name string, value any, _ ColumnType, _ Options) (any, error) {
+			return writeString(name, value)
+		}),
+		DecodeFunc: func(name string, value any, _ ColumnType, _ Options) (any, error) {
+			return readString(name, value)
+		},
+	}
+
+	binaryConverter Converter = ConverterFuncs{
+		EncodeFunc: func(name string, value any, _ ColumnType, opts Options) (any, error) {
+			return writeBytes(name, value, opts.BinaryEncoding)
+		},
+	}
+
+	timeConverter Converter = ConverterFuncs{
+		EncodeFunc: withJSON(writeTime),
+	}
+
+	intConverter Converter = ConverterFuncs{
+		EncodeFunc: withJSON(func(name string, value any, columnType ColumnType, _ Options) (any, error) {
+			minValue, maxValue := intRange(columnType.Name)
+
+			return writeInt(name, value, minValue, maxValue)
+		}),
+		DecodeFunc: func(name string, value any, _ ColumnType, _ Options) (any, error) {
+			return readInt(name, value)
+		},
+	}
+
+	floatConverter Converter = ConverterFuncs{
+		EncodeFunc: withJSON(func(name string, value any, columnType ColumnType, _ Options) (any, error) {
+			return writeFloat(name, value, floatRange(columnType.Name))
+		}),
+		DecodeFunc: func(name string, value any, _ ColumnType, _ Options) (any, error) {
+			return readFloat(name, value)
+		},
+	}
+
+	decimalConverter Converter = ConverterFuncs{
+		EncodeFunc: withJSON(func(name string, value any, columnType ColumnType, _ Options) (any, error) {
+			return writeDecimal(name, value, columnType)
+		}),
+		DecodeFunc: func(name string, value any, _ ColumnType, _ Options) (any, error) {
+			return readDecimal(name, value)
+		},
+	}
+
+	decFloatConverter Converter = ConverterFuncs{
+		EncodeFunc: withJSON(func(name string, value any, columnType ColumnType, _ Options) (any, error) {
+			return writeDecFloat(name, value, columnType)
+		}),
+		DecodeFunc: func(name string, value any, _ ColumnType, _ Options) (any, error) {
+			return readDecimal(name, value)
+		},
+	}
+
+	boolConverter Converter = ConverterFuncs{
+		EncodeFunc: withJSON(func(name string, value any, _ ColumnType, _ Options) (any, error) {
+			return writeBool(name, value)
+		}),
+		DecodeFunc: func(name string, value any, _ ColumnType, _ Options) (any, error) {
+			return readBool(name, value)
+		},
+	}
+)
+
+// withJSON returns a function that writes maps and slices as JSON strings, since DB2 doesn't have json type
+// or similar, and converts other values with the provided function. Byte slices are raw data rather than
+// JSON arrays, so they're converted with the provided function too.
+func withJSON(convert ConvertFunc) ConvertFunc {
+	return func(name string, value any, columnType ColumnType, opts Options) (any, error) {
+		if _, ok := value.([]byte); ok {
+			return convert(name, value, columnType, opts)
+		}
+
+		switch reflect.TypeOf(value).Kind() {
+		case reflect.Map, reflect.Slice:
+			bs, err := json.Marshal(value)
+			if err != nil {
+				return nil, fmt.Errorf("marshal: %w", err)
+			}
+
+			return string(bs), nil
+		default:
+			return convert(name, value, columnType, opts)
+		}
+	}
+}
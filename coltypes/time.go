@@ -0,0 +1,145 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coltypes
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// nanoDigits is a number of fractional digits of seconds Go times can hold.
+	nanoDigits = 9
+
+	// db2TimestampLayout is a layout of DB2 timestamp strings without fractional seconds.
+	db2TimestampLayout = "2006-01-02-15.04.05"
+)
+
+var (
+	// time layouts.
+	layouts = []string{time.RFC3339, time.RFC3339Nano, time.Layout, time.ANSIC, time.UnixDate, time.RubyDate,
+		time.RFC822, time.RFC822Z, time.RFC850, time.RFC1123, time.RFC1123Z, time.RFC3339, time.RFC3339,
+		time.RFC3339Nano, time.Kitchen, time.Stamp, time.StampMilli, time.StampMicro, time.StampNano,
+		"2006-01-02-15.04.05.999999999", "2006-01-02 15:04:05.999999999", "2006-01-02T15:04:05.999999999",
+		"2006-01-02", "15.04.05", "15:04:05"}
+
+	// extraFractionRe matches fractional seconds with more digits than Go times can hold,
+	// the second group contains the extra digits.
+	extraFractionRe = regexp.MustCompile(`([.,]\d{9})(\d+)`)
+)
+
+// writeTime converts the value to a time in the options' location. Strings are parsed,
+// numbers are treated as times since the Unix epoch in the options' unit.
+// Values of TIMESTAMP columns with more than 9 fractional digits are converted to DB2 timestamp strings,
+// so they keep all their digits.
+func writeTime(name string, value any, columnType ColumnType, opts Options) (any, error) {
+	var (
+		t     time.Time
+		extra string
+		err   error
+	)
+
+	switch v := value.(type) {
+	case time.Time:
+		t = v
+	case string:
+		t, extra, err = parseTime(v, opts)
+		if err != nil {
+			return nil, fmt.Errorf("convert value to time.Time: %w", err)
+		}
+	case json.Number:
+		t, err = parseEpoch(v.String(), opts.epochUnit())
+		if err != nil {
+			return nil, invalidValueErr(name, value)
+		}
+	default:
+		f, ok := toFloat(value)
+		if !ok {
+			return nil, invalidValueErr(name, value)
+		}
+
+		t, err = parseEpoch(strconv.FormatFloat(f, 'f', -1, 64), opts.epochUnit())
+		if err != nil {
+			return nil, invalidValueErr(name, value)
+		}
+	}
+
+	t = t.In(opts.location())
+
+	if columnType.Name == timeStamp && columnType.Scale > nanoDigits {
+		return formatTimestamp(t, columnType.Scale, extra), nil
+	}
+
+	return t, nil
+}
+
+// parseTime parses the string by the options' layouts, then by the default ones.
+// Times without zones are interpreted in the options' location.
+// It also returns fractional digits of seconds beyond nanoseconds, which Go times can't hold.
+func parseTime(val string, opts Options) (time.Time, string, error) {
+	var extra string
+
+	if match := extraFractionRe.FindStringSubmatchIndex(val); match != nil {
+		extra = val[match[4]:match[5]]
+		val = val[:match[4]] + val[match[5]:]
+	}
+
+	for _, candidates := range [][]string{opts.TimeLayouts, layouts} {
+		for _, l := range candidates {
+			timeValue, err := time.ParseInLocation(l, val, opts.location())
+			if err != nil {
+				continue
+			}
+
+			return timeValue, extra, nil
+		}
+	}
+
+	return time.Time{}, "", fmt.Errorf("%s - %w", val, ErrInvalidTimeLayout)
+}
+
+// parseEpoch parses a number of units since the Unix epoch.
+func parseEpoch(val string, unit time.Duration) (time.Time, error) {
+	perSecond := int64(time.Second / unit)
+
+	if i, err := strconv.ParseInt(val, 10, 64); err == nil {
+		return time.Unix(i/perSecond, i%perSecond*int64(unit)).UTC(), nil
+	}
+
+	f, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse epoch: %w", err)
+	}
+
+	sec, frac := math.Modf(f / float64(perSecond))
+
+	return time.Unix(int64(sec), int64(math.Round(frac*float64(time.Second)))).UTC(), nil
+}
+
+// formatTimestamp formats the time as a DB2 timestamp string with the precision of fractional seconds.
+// The extra digits are appended to the nanoseconds of the time.
+func formatTimestamp(t time.Time, precision int, extra string) string {
+	fraction := fmt.Sprintf("%09d", t.Nanosecond()) + extra
+	if len(fraction) < precision {
+		fraction += strings.Repeat("0", precision-len(fraction))
+	}
+
+	return t.Format(db2TimestampLayout) + "." + fraction[:precision]
+}
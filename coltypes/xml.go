@@ -0,0 +1,334 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coltypes
+
+import (
+	"database/sql/driver"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// DefaultXMLRootElement is a name of the root element of documents serialized from structured data.
+	DefaultXMLRootElement = "root"
+
+	// xmlItemElement is a name of the elements of arrays that are not values of object fields.
+	xmlItemElement = "item"
+	// xmlAttrPrefix is a prefix of object fields that are serialized as attributes of their element.
+	xmlAttrPrefix = "@"
+	// xmlTextField is an object field that contains the text of its element.
+	xmlTextField = "#text"
+)
+
+// XML is a serialized XML document bound to a statement parameter of an XML column.
+// Writers must bind it as XML, e.g. XMLPARSE(DOCUMENT CAST(? AS CLOB)), since DB2 doesn't cast strings to XML.
+type XML string
+
+// Value implements the driver.Valuer interface, XML documents are sent as strings.
+func (x XML) Value() (driver.Value, error) {
+	return string(x), nil
+}
+
+// xmlConverter writes strings and byte slices as they are and structured data serialized by writeXML,
+// all documents must be well-formed. Values are read as strings.
+var xmlConverter Converter = ConverterFuncs{
+	EncodeFunc: func(name string, value any, _ ColumnType, opts Options) (any, error) {
+		var document string
+
+		switch v := value.(type) {
+		case string:
+			document = v
+		case []byte:
+			document = string(v)
+		default:
+			switch reflect.TypeOf(value).Kind() {
+			case reflect.Map, reflect.Slice, reflect.Array:
+				var err error
+
+				document, err = writeXML(name, value, opts.xmlRootElement())
+				if err != nil {
+					return nil, err
+				}
+			default:
+				return nil, invalidValueErr(name, value)
+			}
+		}
+
+		if err := checkXML(document); err != nil {
+			return nil, malformedXMLErr(name, err)
+		}
+
+		return XML(document), nil
+	},
+	DecodeFunc: func(name string, value any, _ ColumnType, _ Options) (any, error) {
+		return readString(name, value)
+	},
+}
+
+// checkXML returns an error if the document is not well-formed, i.e. it can't be parsed
+// or doesn't contain exactly one root element.
+func checkXML(document string) error {
+	decoder := newXMLDecoder(document)
+
+	var depth, roots int
+
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				roots++
+			}
+
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			if depth == 0 && len(strings.TrimSpace(string(t))) > 0 {
+				return errTextOutsideRoot
+			}
+		}
+	}
+
+	if roots != 1 {
+		return fmt.Errorf("%w: %d", errRootElements, roots)
+	}
+
+	return nil
+}
+
+// newXMLDecoder returns a strict decoder of the document. Documents are Go strings,
+// so the encodings declared by them are ignored.
+func newXMLDecoder(document string) *xml.Decoder {
+	decoder := xml.NewDecoder(strings.NewReader(document))
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	return decoder
+}
+
+// writeXML serializes the value as a document with the root element.
+// Object fields are written as elements, fields prefixed by "@" as attributes, the "#text" field as text.
+// Arrays are written as elements repeated for every item, items of top-level and nested arrays
+// are written as "item" elements.
+func writeXML(name string, value any, root string) (string, error) {
+	var (
+		sb  strings.Builder
+		err error
+	)
+
+	rv := reflect.ValueOf(value)
+
+	switch {
+	case !isXMLName(root):
+		err = fmt.Errorf("invalid root element name %q", root)
+	case isXMLArray(rv):
+		sb.WriteString("<" + root + ">")
+		err = writeXMLElement(&sb, xmlItemElement, rv)
+		sb.WriteString("</" + root + ">")
+	default:
+		err = writeXMLElement(&sb, root, rv)
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("%w: serialize: %v", invalidValueErr(name, value), err)
+	}
+
+	return sb.String(), nil
+}
+
+// writeXMLElement writes the value as the element, arrays are written as the element repeated for every item.
+func writeXMLElement(sb *strings.Builder, element string, value reflect.Value) error {
+	if !isXMLName(element) {
+		return fmt.Errorf("invalid element name %q", element)
+	}
+
+	for value.Kind() == reflect.Interface || value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			sb.WriteString("<" + element + "/>")
+
+			return nil
+		}
+
+		value = value.Elem()
+	}
+
+	if value.Kind() == reflect.Map {
+		return writeXMLObject(sb, element, value)
+	}
+
+	if isXMLArray(value) {
+		for i := 0; i < value.Len(); i++ {
+			item := value.Index(i)
+			for item.Kind() == reflect.Interface && !item.IsNil() {
+				item = item.Elem()
+			}
+
+			// nested arrays can't be repeated elements, so they're wrapped by the element.
+			if isXMLArray(item) {
+				sb.WriteString("<" + element + ">")
+
+				if err := writeXMLElement(sb, xmlItemElement, item); err != nil {
+					return err
+				}
+
+				sb.WriteString("</" + element + ">")
+
+				continue
+			}
+
+			if err := writeXMLElement(sb, element, item); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	text, err := writeXMLText(value.Interface())
+	if err != nil {
+		return err
+	}
+
+	sb.WriteString("<" + element + ">" + text + "</" + element + ">")
+
+	return nil
+}
+
+// isXMLArray reports whether the value is an array of elements, byte slices are written as text.
+func isXMLArray(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		return value.Type().Elem().Kind() != reflect.Uint8
+	default:
+		return false
+	}
+}
+
+// writeXMLObject writes the map as the element with the attributes, text and child elements of its fields,
+// sorted by names.
+func writeXMLObject(sb *strings.Builder, element string, value reflect.Value) error {
+	if value.Type().Key().Kind() != reflect.String {
+		return fmt.Errorf("%q: object keys must be strings", element)
+	}
+
+	keys := make([]string, 0, value.Len())
+	for _, key := range value.MapKeys() {
+		keys = append(keys, key.String())
+	}
+
+	sort.Strings(keys)
+
+	sb.WriteString("<" + element)
+
+	for _, key := range keys {
+		if !strings.HasPrefix(key, xmlAttrPrefix) {
+			continue
+		}
+
+		attr := strings.TrimPrefix(key, xmlAttrPrefix)
+		if !isXMLName(attr) {
+			return fmt.Errorf("invalid attribute name %q", attr)
+		}
+
+		text, err := writeXMLText(value.MapIndex(reflect.ValueOf(key).Convert(value.Type().Key())).Interface())
+		if err != nil {
+			return err
+		}
+
+		sb.WriteString(" " + attr + `="` + text + `"`)
+	}
+
+	sb.WriteString(">")
+
+	for _, key := range keys {
+		if strings.HasPrefix(key, xmlAttrPrefix) {
+			continue
+		}
+
+		field := value.MapIndex(reflect.ValueOf(key).Convert(value.Type().Key()))
+
+		if key == xmlTextField {
+			text, err := writeXMLText(field.Interface())
+			if err != nil {
+				return err
+			}
+
+			sb.WriteString(text)
+
+			continue
+		}
+
+		if err := writeXMLElement(sb, key, field); err != nil {
+			return err
+		}
+	}
+
+	sb.WriteString("</" + element + ">")
+
+	return nil
+}
+
+// writeXMLText converts the scalar value to escaped text, nil is written as an empty text.
+func writeXMLText(value any) (string, error) {
+	if value == nil {
+		return "", nil
+	}
+
+	text, err := writeString("", value)
+	if err != nil {
+		return "", fmt.Errorf("unsupported value %v (%T)", value, value)
+	}
+
+	var sb strings.Builder
+	if err = xml.EscapeText(&sb, []byte(text)); err != nil {
+		return "", fmt.Errorf("escape text: %w", err)
+	}
+
+	return sb.String(), nil
+}
+
+// isXMLName reports whether the name is a valid name of an XML element or attribute without a namespace.
+func isXMLName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for i, r := range name {
+		switch {
+		case unicode.IsLetter(r), r == '_':
+		case i > 0 && (unicode.IsDigit(r) || r == '-' || r == '.'):
+		default:
+			return false
+		}
+	}
+
+	return true
+}
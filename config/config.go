@@ -30,7 +30,7 @@ const (
 // Config contains configurable values
 // shared between source and destination DB2 connector.
 type Config struct {
-	// Connection string connection to DB2 database.
+	// Connection string connection to DB2 database, either set as it is or assembled from the discrete parameters.
 	Connection string `validate:"required"`
 	// Table is a name of the table that the connector should write to or read from.
 	Table string `validate:"required,max=128"`
@@ -40,8 +40,13 @@ type Config struct {
 
 // Parse attempts to parse a provided map[string]string into a Config struct.
 func Parse(cfg map[string]string) (Config, error) {
+	connection, err := parseConnection(cfg)
+	if err != nil {
+		return Config{}, fmt.Errorf("parse connection: %w", err)
+	}
+
 	config := Config{
-		Connection: cfg[KeyConnection],
+		Connection: connection,
 		Table:      strings.ToUpper(cfg[KeyTable]),
 		Key:        strings.ToUpper(cfg[KeyPrimaryKey]),
 	}
@@ -0,0 +1,290 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	KeyHost          string = "host"
+	KeyPort          string = "port"
+	KeyDatabase      string = "database"
+	KeyUser          string = "user"
+	KeyPassword      string = "password"
+	KeyCurrentSchema string = "currentSchema"
+	// KeyConnectionParams is a key of extra CLI keywords of the connection string separated by semicolons,
+	// e.g. "PROGRAMNAME=conduit;KEEPALIVE=30".
+	KeyConnectionParams string = "connectionParams"
+	// KeyLoginTimeout is a key of the time to wait for a connection to be established, e.g. "10s".
+	KeyLoginTimeout string = "loginTimeout"
+)
+
+// DefaultPort is a default port of DB2 servers.
+const DefaultPort = 50000
+
+// CLI keywords of the connection string.
+const (
+	keywordHost          = "HOSTNAME"
+	keywordPort          = "PORT"
+	keywordDatabase      = "DATABASE"
+	keywordUser          = "UID"
+	keywordPassword      = "PWD"
+	keywordProtocol      = "PROTOCOL"
+	keywordCurrentSchema = "CURRENTSCHEMA"
+	keywordLoginTimeout  = "CONNECTTIMEOUT"
+
+	// protocolTCPIP is the only protocol go_ibm_db connects by.
+	protocolTCPIP = "TCPIP"
+)
+
+// maskedPassword replaces passwords in logs and error messages.
+const maskedPassword = "****"
+
+// parseConnection returns the raw connection string, if it's set, or the connection string assembled
+// from the discrete connection parameters. The login timeout is added to either of them.
+// Parsing errors never contain the password.
+func parseConnection(cfg map[string]string) (string, error) {
+	loginTimeout, err := parseDuration(cfg, KeyLoginTimeout, 0)
+	if err != nil {
+		return "", err
+	}
+
+	if cfg[KeyConnection] != "" {
+		return parseRawConnection(cfg, loginTimeout)
+	}
+
+	if cfg[KeyHost] == "" {
+		return "", fmt.Errorf("either %q or %q value must be set", KeyConnection, KeyHost)
+	}
+
+	if cfg[KeyDatabase] == "" {
+		return "", fmt.Errorf("%q value must be set", KeyDatabase)
+	}
+
+	port := DefaultPort
+	if cfg[KeyPort] != "" {
+		var err error
+
+		port, err = strconv.Atoi(cfg[KeyPort])
+		if err != nil || port < 1 || port > 65535 {
+			return "", fmt.Errorf("%q value must be a port number", KeyPort)
+		}
+	}
+
+	ssl, err := parseSSL(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	if (cfg[KeyUser] == "") != (cfg[KeyPassword] == "") {
+		return "", fmt.Errorf("%q and %q values must be set together", KeyUser, KeyPassword)
+	}
+
+	keywords := [][2]string{
+		{keywordHost, cfg[KeyHost]},
+		{keywordPort, strconv.Itoa(port)},
+		{keywordDatabase, cfg[KeyDatabase]},
+		{keywordProtocol, protocolTCPIP},
+		{keywordUser, cfg[KeyUser]},
+		{keywordPassword, cfg[KeyPassword]},
+		{keywordCurrentSchema, cfg[KeyCurrentSchema]},
+		{keywordLoginTimeout, formatLoginTimeout(loginTimeout)},
+	}
+
+	params, err := parseConnectionParams(cfg[KeyConnectionParams])
+	if err != nil {
+		return "", err
+	}
+
+	keywords = append(keywords, ssl...)
+
+	return buildConnection(append(keywords, params...)), nil
+}
+
+// parseRawConnection returns the raw connection string with the login timeout keyword appended,
+// the discrete connection parameters must not be set along with it.
+func parseRawConnection(cfg map[string]string, loginTimeout time.Duration) (string, error) {
+	keys := append([]string{KeyHost, KeyPort, KeyDatabase, KeyUser, KeyPassword,
+		KeyCurrentSchema, KeyConnectionParams}, sslKeys...)
+
+	for _, key := range keys {
+		if cfg[key] != "" {
+			return "", fmt.Errorf("%q and %q must not be set together", KeyConnection, key)
+		}
+	}
+
+	if loginTimeout == 0 {
+		return cfg[KeyConnection], nil
+	}
+
+	if loginTimeoutKeyword.MatchString(cfg[KeyConnection]) {
+		return "", fmt.Errorf("%q and the %s keyword of %q must not be set together",
+			KeyLoginTimeout, keywordLoginTimeout, KeyConnection)
+	}
+
+	return strings.TrimRight(cfg[KeyConnection], "; ") + ";" +
+		buildConnection([][2]string{{keywordLoginTimeout, formatLoginTimeout(loginTimeout)}}), nil
+}
+
+// loginTimeoutKeyword matches the login timeout keyword of a connection string.
+var loginTimeoutKeyword = regexp.MustCompile(`(?i)(^|;)\s*` + keywordLoginTimeout + `\s*=`)
+
+// parseConnectionParams parses the extra CLI keywords, they must not override the discrete parameters.
+func parseConnectionParams(value string) ([][2]string, error) {
+	var params [][2]string
+
+	for _, param := range strings.Split(value, ";") {
+		if strings.TrimSpace(param) == "" {
+			continue
+		}
+
+		keyword, value, ok := strings.Cut(param, "=")
+		keyword = strings.ToUpper(strings.TrimSpace(keyword))
+
+		if !ok || keyword == "" {
+			return nil, fmt.Errorf("%q: keywords must be set as KEYWORD=value", KeyConnectionParams)
+		}
+
+		switch keyword {
+		case keywordHost, keywordPort, keywordDatabase, keywordProtocol,
+			keywordUser, keywordPassword, keywordCurrentSchema, keywordLoginTimeout:
+			return nil, fmt.Errorf("%q: keyword %s must be set by its parameter", KeyConnectionParams, keyword)
+		}
+
+		if isSSLKeyword(keyword) {
+			return nil, fmt.Errorf("%q: keyword %s must be set by the SSL parameters", KeyConnectionParams, keyword)
+		}
+
+		params = append(params, [2]string{keyword, strings.TrimSpace(value)})
+	}
+
+	return params, nil
+}
+
+// formatLoginTimeout returns the login timeout in whole seconds, rounded up, since the keyword
+// doesn't accept fractions. The zero timeout is left out, so the driver's default applies.
+func formatLoginTimeout(timeout time.Duration) string {
+	if timeout == 0 {
+		return ""
+	}
+
+	return strconv.FormatInt(int64((timeout+time.Second-1)/time.Second), 10)
+}
+
+// buildConnection joins the keywords and their escaped values into a connection string,
+// keywords with empty values are left out.
+func buildConnection(keywords [][2]string) string {
+	parts := make([]string, 0, len(keywords))
+
+	for _, keyword := range keywords {
+		if keyword[1] != "" {
+			parts = append(parts, keyword[0]+"="+escapeConnectionValue(keyword[1]))
+		}
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// escapeConnectionValue encloses values with separators, braces or surrounding spaces in braces,
+// closing braces are doubled.
+func escapeConnectionValue(value string) string {
+	if !strings.ContainsAny(value, ";{}=") && strings.TrimSpace(value) == value {
+		return value
+	}
+
+	return "{" + strings.ReplaceAll(value, "}", "}}") + "}"
+}
+
+// passwordKeyword matches the password keyword of a connection string and the following equals sign.
+var passwordKeyword = regexp.MustCompile(`(?i)\b` + keywordPassword + `\s*=\s*`)
+
+// connectionValueLength returns the length of the value the rest of the connection string starts with,
+// braces of escaped values included.
+func connectionValueLength(rest string) int {
+	if !strings.HasPrefix(rest, "{") {
+		if end := strings.IndexByte(rest, ';'); end >= 0 {
+			return end
+		}
+
+		return len(rest)
+	}
+
+	for i := 1; i < len(rest); i++ {
+		if rest[i] != '}' {
+			continue
+		}
+
+		// a doubled closing brace is a part of the value.
+		if i+1 < len(rest) && rest[i+1] == '}' {
+			i++
+
+			continue
+		}
+
+		return i + 1
+	}
+
+	return len(rest)
+}
+
+// MaskPassword returns the err with the values of the password keywords of connection strings in its message masked,
+// the original error is still available by errors.Is and errors.As.
+func MaskPassword(err error) error {
+	if err == nil || !passwordKeyword.MatchString(err.Error()) {
+		return err
+	}
+
+	return &maskedError{err: err}
+}
+
+// maskedError is an error whose message has the passwords masked.
+type maskedError struct {
+	err error
+}
+
+// Error returns the message of the original error with the passwords masked.
+func (e *maskedError) Error() string {
+	return maskPassword(e.err.Error())
+}
+
+// Unwrap returns the original error.
+func (e *maskedError) Unwrap() error {
+	return e.err
+}
+
+// maskPassword replaces the values of the password keywords of the connection strings in the text,
+// the rest of the text is left as it is.
+func maskPassword(text string) string {
+	var sb strings.Builder
+
+	for {
+		loc := passwordKeyword.FindStringIndex(text)
+		if loc == nil {
+			sb.WriteString(text)
+
+			return sb.String()
+		}
+
+		sb.WriteString(text[:loc[1]])
+		sb.WriteString(maskedPassword)
+
+		text = text[loc[1]+connectionValueLength(text[loc[1]:]):]
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseConnection(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		cfg     map[string]string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "raw connection string",
+			cfg:  map[string]string{KeyConnection: testConnection},
+			want: testConnection,
+		},
+		{
+			name: "discrete parameters",
+			cfg: map[string]string{
+				KeyHost:          "localhost",
+				KeyDatabase:      "testdb",
+				KeyUser:          "DB2INST1",
+				KeyPassword:      "p;w{d}",
+				KeyCurrentSchema: "SALES",
+			},
+			want: "HOSTNAME=localhost;PORT=50000;DATABASE=testdb;PROTOCOL=TCPIP;UID=DB2INST1;PWD={p;w{d}}};" +
+				"CURRENTSCHEMA=SALES",
+		},
+		{
+			name: "extra keywords",
+			cfg: map[string]string{
+				KeyHost:             "db2.example.com",
+				KeyPort:             "50001",
+				KeyDatabase:         "testdb",
+				KeyConnectionParams: " keepAlive = 30; ;ProgramName=conduit",
+			},
+			want: "HOSTNAME=db2.example.com;PORT=50001;DATABASE=testdb;PROTOCOL=TCPIP;KEEPALIVE=30;" +
+				"PROGRAMNAME=conduit",
+		},
+		{
+			name: "login timeout",
+			cfg: map[string]string{
+				KeyHost: "localhost", KeyDatabase: "testdb", KeyLoginTimeout: "1500ms",
+			},
+			want: "HOSTNAME=localhost;PORT=50000;DATABASE=testdb;PROTOCOL=TCPIP;CONNECTTIMEOUT=2",
+		},
+		{
+			name: "invalid login timeout",
+			cfg: map[string]string{
+				KeyHost: "localhost", KeyDatabase: "testdb", KeyLoginTimeout: "-1s",
+			},
+			wantErr: true,
+		},
+		{
+			name: "login timeout in extra keywords",
+			cfg: map[string]string{
+				KeyHost: "localhost", KeyDatabase: "testdb", KeyConnectionParams: "CONNECTTIMEOUT=10",
+			},
+			wantErr: true,
+		},
+		{
+			name: "raw connection string with login timeout",
+			cfg:  map[string]string{KeyConnection: testConnection + ";", KeyLoginTimeout: "10s"},
+			want: testConnection + ";CONNECTTIMEOUT=10",
+		},
+		{
+			name: "raw connection string with login timeout keyword",
+			cfg: map[string]string{
+				KeyConnection: testConnection + "; connectTimeout=5", KeyLoginTimeout: "10s",
+			},
+			wantErr: true,
+		},
+		{
+			name:    "raw connection string with invalid login timeout",
+			cfg:     map[string]string{KeyConnection: testConnection, KeyLoginTimeout: "-1s"},
+			wantErr: true,
+		},
+		{
+			name:    "raw connection string with discrete parameters",
+			cfg:     map[string]string{KeyConnection: testConnection, KeyHost: "localhost"},
+			wantErr: true,
+		},
+		{
+			name:    "no host",
+			cfg:     map[string]string{KeyDatabase: "testdb"},
+			wantErr: true,
+		},
+		{
+			name:    "no database",
+			cfg:     map[string]string{KeyHost: "localhost"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid port",
+			cfg:     map[string]string{KeyHost: "localhost", KeyDatabase: "testdb", KeyPort: "70000"},
+			wantErr: true,
+		},
+		{
+			name:    "user without password",
+			cfg:     map[string]string{KeyHost: "localhost", KeyDatabase: "testdb", KeyUser: "DB2INST1"},
+			wantErr: true,
+		},
+		{
+			name: "extra keyword overrides a parameter",
+			cfg: map[string]string{
+				KeyHost: "localhost", KeyDatabase: "testdb", KeyConnectionParams: "pwd=secret",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid extra keyword",
+			cfg: map[string]string{
+				KeyHost: "localhost", KeyDatabase: "testdb", KeyConnectionParams: "PROGRAMNAME",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseConnection(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseConnection() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+
+			if got != tt.want {
+				t.Errorf("parseConnection() = %q, want %q", got, tt.want)
+			}
+
+			if err != nil && strings.Contains(err.Error(), "secret") {
+				t.Errorf("parseConnection() error contains the password: %v", err)
+			}
+		})
+	}
+}
+
+func TestMaskPassword(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{
+			name:    "plain password",
+			message: "connect: " + testConnection,
+			want:    "connect: HOSTNAME=localhost;DATABASE=testdb;PORT=50000;UID=DB2INST1;PWD=****",
+		},
+		{
+			name:    "escaped password",
+			message: "connect: HOSTNAME=localhost;pwd = {p;w{d}}};DATABASE=testdb",
+			want:    "connect: HOSTNAME=localhost;pwd = ****;DATABASE=testdb",
+		},
+		{
+			name:    "unrelated text with the password",
+			message: "connect to pwd.example.com: HOSTNAME=pwd.example.com;PWD=pwd;DATABASE=pwd",
+			want:    "connect to pwd.example.com: HOSTNAME=pwd.example.com;PWD=****;DATABASE=pwd",
+		},
+		{
+			name:    "several connection strings",
+			message: "PWD=first;UID=a, PWD=second",
+			want:    "PWD=****;UID=a, PWD=****",
+		},
+		{
+			name:    "no password",
+			message: "connect: HOSTNAME=localhost;DATABASE=testdb",
+			want:    "connect: HOSTNAME=localhost;DATABASE=testdb",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			original := errors.New(tt.message)
+
+			err := MaskPassword(original)
+			if err.Error() != tt.want {
+				t.Errorf("MaskPassword() = %q, want %q", err.Error(), tt.want)
+			}
+
+			if !errors.Is(err, original) {
+				t.Errorf("MaskPassword() must wrap %v", original)
+			}
+		})
+	}
+
+	if err := MaskPassword(nil); err != nil {
+		t.Errorf("MaskPassword(nil) = %v, want nil", err)
+	}
+}
@@ -0,0 +1,640 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/conduitio-labs/conduit-connector-db2/coltypes"
+	"github.com/conduitio-labs/conduit-connector-db2/validator"
+)
+
+const (
+	KeyWriteMode         string = "writeMode"
+	KeyVersionColumn     string = "versionColumn"
+	KeyPartialUpdate     string = "partialUpdate"
+	KeyKeepMissingFields string = "keepMissingFields"
+	KeySCD2ValidFrom     string = "scd2.validFromColumn"
+	KeySCD2ValidTo       string = "scd2.validToColumn"
+	KeySCD2Current       string = "scd2.currentColumn"
+
+	// KeyPrefixAuditColumns is a prefix of keys that map audit columns to the record's fields,
+	// e.g. "auditColumns.SYNC_OP" = "operation".
+	KeyPrefixAuditColumns string = "auditColumns."
+	// KeyPrefixColumnMapping is a prefix of keys that map the record's fields to columns,
+	// e.g. "columnMapping.address.city" = "CITY".
+	KeyPrefixColumnMapping string = "columnMapping."
+	KeyIncludeFields       string = "includeFields"
+	KeyExcludeFields       string = "excludeFields"
+	KeyFlatten             string = "flatten"
+	KeyFlattenSeparator    string = "flattenSeparator"
+	KeyUnknownColumns      string = "unknownColumns"
+	KeyAutoCreateTable     string = "autoCreateTable"
+	KeyTimeZone            string = "timeZone"
+	// KeyTimeLayouts is a key of Go time layouts separated by TimeLayoutsSeparator,
+	// since the layouts may contain commas.
+	KeyTimeLayouts string = "timeLayouts"
+	KeyEpochUnit   string = "epochUnit"
+
+	KeyOverflowPolicy string = "overflowPolicy"
+	// KeyPrefixOverflowPolicy is a prefix of keys that set overflow policies of columns,
+	// e.g. "overflowPolicy.COMMENT" = "truncate".
+	KeyPrefixOverflowPolicy string = "overflowPolicy."
+
+	KeyBinaryEncoding string = "binaryEncoding"
+	KeyXMLRootElement string = "xmlRootElement"
+
+	KeyLOBMaxInlineSize   string = "lob.maxInlineSize"
+	KeyLOBPolicy          string = "lob.policy"
+	KeyLOBTruncatedColumn string = "lob.truncatedColumn"
+
+	KeyRetryMaxAttempts    string = "retry.maxAttempts"
+	KeyRetryInitialBackoff string = "retry.initialBackoff"
+	KeyRetryMaxBackoff     string = "retry.maxBackoff"
+	KeyRetryMaxElapsedTime string = "retry.maxElapsedTime"
+
+	KeyReconnectMaxAttempts string = "reconnect.maxAttempts"
+
+	KeyDeadLetterTable string = "deadLetterTable"
+
+	KeyPoolMaxOpenConns    string = "pool.maxOpenConns"
+	KeyPoolMaxIdleConns    string = "pool.maxIdleConns"
+	KeyPoolConnMaxLifetime string = "pool.connMaxLifetime"
+	KeyPoolConnMaxIdleTime string = "pool.connMaxIdleTime"
+
+	KeyQueryTimeout string = "queryTimeout"
+	// KeyLockTimeout is a key of the CURRENT LOCK TIMEOUT special register set for every connection,
+	// in seconds, -1 waits for locks without a limit.
+	KeyLockTimeout string = "lockTimeout"
+)
+
+// WriteMode defines how the destination writes records to a table.
+type WriteMode string
+
+const (
+	// WriteModeUpsert merges records into a table matching rows by the key column.
+	WriteModeUpsert WriteMode = "upsert"
+	// WriteModeAppend inserts records as new rows without any key matching.
+	WriteModeAppend WriteMode = "append"
+	// WriteModeSCD2 keeps the history of rows as a slowly changing dimension (Type 2).
+	WriteModeSCD2 WriteMode = "scd2"
+)
+
+// UnknownColumnsPolicy defines what the destination does with payload fields that don't exist in a table.
+type UnknownColumnsPolicy string
+
+const (
+	// UnknownColumnsFail fails writing the record.
+	UnknownColumnsFail UnknownColumnsPolicy = "fail"
+	// UnknownColumnsIgnore drops the fields from the record.
+	UnknownColumnsIgnore UnknownColumnsPolicy = "ignore"
+	// UnknownColumnsEvolve adds columns for the fields to the table.
+	UnknownColumnsEvolve UnknownColumnsPolicy = "evolve"
+)
+
+// EpochUnit is a unit of times represented by numbers since the Unix epoch.
+type EpochUnit string
+
+const (
+	EpochUnitSeconds      EpochUnit = "s"
+	EpochUnitMilliseconds EpochUnit = "ms"
+	EpochUnitMicroseconds EpochUnit = "us"
+	EpochUnitNanoseconds  EpochUnit = "ns"
+)
+
+// Duration returns a duration of the unit.
+func (u EpochUnit) Duration() time.Duration {
+	switch u {
+	case EpochUnitSeconds:
+		return time.Second
+	case EpochUnitMicroseconds:
+		return time.Microsecond
+	case EpochUnitNanoseconds:
+		return time.Nanosecond
+	default:
+		return time.Millisecond
+	}
+}
+
+// LOBPolicy defines what the destination does with LOB values larger than the maximum inline size.
+type LOBPolicy string
+
+const (
+	// LOBChunked writes the first chunk of the value with the row and appends the remaining chunks to it.
+	LOBChunked LOBPolicy = "chunked"
+	// LOBTruncate truncates the value to the maximum inline size.
+	LOBTruncate LOBPolicy = "truncate"
+	// LOBSkip leaves the column out of the write.
+	LOBSkip LOBPolicy = "skip"
+)
+
+// time defaults.
+const (
+	DefaultTimeZone  = "UTC"
+	DefaultEpochUnit = EpochUnitMilliseconds
+)
+
+// TimeLayoutsSeparator separates layouts of the KeyTimeLayouts value.
+const TimeLayoutsSeparator = "|"
+
+// DefaultFlattenSeparator is a default separator of the flattened nested objects and their fields.
+const DefaultFlattenSeparator = "_"
+
+// DefaultXMLRootElement is a default name of the root element of XML documents serialized from objects and arrays.
+const DefaultXMLRootElement = "root"
+
+// retry defaults.
+const (
+	DefaultRetryMaxAttempts    = 5
+	DefaultRetryInitialBackoff = 100 * time.Millisecond
+	DefaultRetryMaxBackoff     = 5 * time.Second
+	DefaultRetryMaxElapsedTime = time.Minute
+)
+
+// DefaultReconnectMaxAttempts is a default maximum number of attempts to reopen a lost connection.
+const DefaultReconnectMaxAttempts = 3
+
+// DefaultPoolMaxIdleConns is a default maximum number of idle connections, the same as the database/sql one.
+const DefaultPoolMaxIdleConns = 2
+
+// lock timeout limits of the CURRENT LOCK TIMEOUT special register.
+const (
+	minLockTimeout = -1
+	maxLockTimeout = 32767
+)
+
+// maxColumnLength is a maximum length of a DB2 column name.
+const maxColumnLength = 128
+
+// default SCD2 column names.
+const (
+	DefaultSCD2ValidFrom = "VALID_FROM"
+	DefaultSCD2ValidTo   = "VALID_TO"
+	DefaultSCD2Current   = "IS_CURRENT"
+)
+
+// audit column sources.
+const (
+	// AuditSourceOperation is the record's operation, e.g. "create".
+	AuditSourceOperation = "operation"
+	// AuditSourcePosition is the record's position.
+	AuditSourcePosition = "position"
+	// AuditSourceCreatedAt is the time when the record was created in the source.
+	AuditSourceCreatedAt = "createdAt"
+	// AuditSourceReadAt is the time when the record was read from the source.
+	AuditSourceReadAt = "readAt"
+	// AuditSourcePrefixMetadata is a prefix of the record's metadata keys, e.g. "metadata.opencdc.readAt".
+	AuditSourcePrefixMetadata = "metadata."
+)
+
+// Destination contains configurable values for the DB2 destination connector.
+type Destination struct {
+	Config
+
+	// WriteMode defines how records are written to a table.
+	WriteMode WriteMode `validate:"oneof=upsert append scd2"`
+	// VersionColumn is a column name used to skip stale updates and deletes, optional.
+	VersionColumn string `validate:"max=128"`
+	// PartialUpdate enables updating only the changed columns of records
+	// that contain payloads both before and after the change.
+	PartialUpdate bool
+	// KeepMissingFields leaves columns of the fields missing in the payload after the change
+	// unchanged during a partial update, instead of setting them to NULL.
+	KeepMissingFields bool
+	// SCD2ValidFrom is a column with the time since which a version of the row is valid.
+	SCD2ValidFrom string `validate:"max=128"`
+	// SCD2ValidTo is a column with the time until which a version of the row is valid.
+	SCD2ValidTo string `validate:"max=128"`
+	// SCD2Current is a column that flags the current version of the row.
+	SCD2Current string `validate:"max=128"`
+	// AuditColumns maps audit column names to the sources of their values.
+	AuditColumns map[string]string
+	// ColumnMapping maps the record's field paths to column names, nested fields are separated by dots.
+	ColumnMapping map[string]string
+	// IncludeFields contains field paths to write, if it's empty all fields are written.
+	IncludeFields []string
+	// ExcludeFields contains field paths that are not written.
+	ExcludeFields []string
+	// Flatten enables expanding nested objects into columns prefixed by their names.
+	Flatten bool
+	// FlattenSeparator separates names of the flattened nested objects and their fields.
+	FlattenSeparator string `validate:"required,max=16"`
+	// UnknownColumns defines what to do with payload fields that don't exist in a table.
+	UnknownColumns UnknownColumnsPolicy `validate:"oneof=fail ignore evolve"`
+	// AutoCreateTable enables creating tables that don't exist from the first records written to them.
+	AutoCreateTable bool
+	// TimeZone is a name of the time zone used to interpret and render dates and times, e.g. "Europe/Kyiv".
+	TimeZone string `validate:"required"`
+	// TimeLayouts contains Go layouts tried before the default ones when times are parsed from strings.
+	TimeLayouts []string
+	// EpochUnit is a unit of times represented by numbers since the Unix epoch.
+	EpochUnit EpochUnit `validate:"oneof=s ms us ns"`
+	// OverflowPolicy is a default policy for values that don't fit their columns.
+	OverflowPolicy coltypes.OverflowPolicy `validate:"oneof=fail truncate null"`
+	// ColumnOverflowPolicies maps column names to their overflow policies, overriding the default one.
+	ColumnOverflowPolicies map[string]coltypes.OverflowPolicy
+	// BinaryEncoding defines how strings written to binary columns are decoded.
+	BinaryEncoding coltypes.BinaryEncoding `validate:"oneof=raw base64 hex"`
+	// XMLRootElement is a name of the root element of XML documents serialized from objects and arrays.
+	XMLRootElement string `validate:"required,max=128"`
+	// LOBMaxInlineSize is a maximum size in bytes of LOB values written with their rows, 0 means no limit.
+	LOBMaxInlineSize int `validate:"gte=0"`
+	// LOBPolicy defines what to do with LOB values larger than the LOBMaxInlineSize.
+	LOBPolicy LOBPolicy `validate:"oneof=chunked truncate skip"`
+	// LOBTruncatedColumn is a column that flags rows with truncated LOB values, optional.
+	LOBTruncatedColumn string `validate:"max=128"`
+	// RetryMaxAttempts is a maximum number of attempts of statements and transactions failed
+	// with transient errors, 1 disables retries.
+	RetryMaxAttempts int `validate:"gte=1"`
+	// RetryInitialBackoff is a delay before the first retry, it's doubled for every next retry.
+	RetryInitialBackoff time.Duration
+	// RetryMaxBackoff is a maximum delay between retries.
+	RetryMaxBackoff time.Duration
+	// RetryMaxElapsedTime is a total time budget of the attempts, 0 means no limit.
+	RetryMaxElapsedTime time.Duration
+	// ReconnectMaxAttempts is a maximum number of attempts to reopen a lost connection, 0 disables reconnects.
+	ReconnectMaxAttempts int `validate:"gte=0"`
+	// DeadLetterTable is a table records that fail with permanent errors are written to, optional.
+	DeadLetterTable string `validate:"max=128"`
+	// PoolMaxOpenConns is a maximum number of open connections, 0 means no limit.
+	PoolMaxOpenConns int `validate:"gte=0"`
+	// PoolMaxIdleConns is a maximum number of idle connections, 0 means idle connections are not kept.
+	PoolMaxIdleConns int `validate:"gte=0"`
+	// PoolConnMaxLifetime is a maximum time a connection is reused for, 0 means no limit.
+	PoolConnMaxLifetime time.Duration
+	// PoolConnMaxIdleTime is a maximum time a connection stays idle, 0 means no limit.
+	PoolConnMaxIdleTime time.Duration
+	// QueryTimeout is a maximum execution time of every statement that writes records, 0 means no limit.
+	QueryTimeout time.Duration
+	// LockTimeout is a number of seconds statements wait for locks, -1 means no limit,
+	// nil leaves the server's default.
+	LockTimeout *int
+}
+
+// ParseDestination attempts to parse a provided map[string]string into a Destination struct.
+func ParseDestination(cfg map[string]string) (Destination, error) {
+	connection, err := parseConnection(cfg)
+	if err != nil {
+		return Destination{}, fmt.Errorf("parse connection: %w", err)
+	}
+
+	config := Destination{
+		Config: Config{
+			Connection: connection,
+			Table:      strings.ToUpper(cfg[KeyTable]),
+			Key:        strings.ToUpper(cfg[KeyPrimaryKey]),
+		},
+		WriteMode:            WriteModeUpsert,
+		VersionColumn:        strings.ToUpper(cfg[KeyVersionColumn]),
+		SCD2ValidFrom:        DefaultSCD2ValidFrom,
+		SCD2ValidTo:          DefaultSCD2ValidTo,
+		SCD2Current:          DefaultSCD2Current,
+		IncludeFields:        parseList(cfg[KeyIncludeFields]),
+		ExcludeFields:        parseList(cfg[KeyExcludeFields]),
+		FlattenSeparator:     DefaultFlattenSeparator,
+		UnknownColumns:       UnknownColumnsFail,
+		TimeZone:             DefaultTimeZone,
+		EpochUnit:            DefaultEpochUnit,
+		OverflowPolicy:       coltypes.OverflowFail,
+		BinaryEncoding:       coltypes.BinaryRaw,
+		XMLRootElement:       DefaultXMLRootElement,
+		LOBPolicy:            LOBChunked,
+		LOBTruncatedColumn:   strings.ToUpper(cfg[KeyLOBTruncatedColumn]),
+		RetryMaxAttempts:     DefaultRetryMaxAttempts,
+		RetryInitialBackoff:  DefaultRetryInitialBackoff,
+		RetryMaxBackoff:      DefaultRetryMaxBackoff,
+		RetryMaxElapsedTime:  DefaultRetryMaxElapsedTime,
+		ReconnectMaxAttempts: DefaultReconnectMaxAttempts,
+		DeadLetterTable:      strings.ToUpper(cfg[KeyDeadLetterTable]),
+		PoolMaxIdleConns:     DefaultPoolMaxIdleConns,
+	}
+
+	if cfg[KeyLOBPolicy] != "" {
+		config.LOBPolicy = LOBPolicy(strings.ToLower(cfg[KeyLOBPolicy]))
+	}
+
+	if cfg[KeyXMLRootElement] != "" {
+		config.XMLRootElement = cfg[KeyXMLRootElement]
+	}
+
+	if cfg[KeyBinaryEncoding] != "" {
+		config.BinaryEncoding = coltypes.BinaryEncoding(strings.ToLower(cfg[KeyBinaryEncoding]))
+	}
+
+	if cfg[KeyOverflowPolicy] != "" {
+		config.OverflowPolicy = coltypes.OverflowPolicy(strings.ToLower(cfg[KeyOverflowPolicy]))
+	}
+
+	if cfg[KeyTimeZone] != "" {
+		config.TimeZone = cfg[KeyTimeZone]
+	}
+
+	if _, err := time.LoadLocation(config.TimeZone); err != nil {
+		return Destination{}, fmt.Errorf("%q: load time zone: %w", KeyTimeZone, err)
+	}
+
+	if cfg[KeyEpochUnit] != "" {
+		config.EpochUnit = EpochUnit(strings.ToLower(cfg[KeyEpochUnit]))
+	}
+
+	for _, layout := range strings.Split(cfg[KeyTimeLayouts], TimeLayoutsSeparator) {
+		if layout = strings.TrimSpace(layout); layout != "" {
+			config.TimeLayouts = append(config.TimeLayouts, layout)
+		}
+	}
+
+	if cfg[KeyUnknownColumns] != "" {
+		config.UnknownColumns = UnknownColumnsPolicy(strings.ToLower(cfg[KeyUnknownColumns]))
+	}
+
+	if cfg[KeyFlattenSeparator] != "" {
+		config.FlattenSeparator = cfg[KeyFlattenSeparator]
+	}
+
+	if cfg[KeyWriteMode] != "" {
+		config.WriteMode = WriteMode(strings.ToLower(cfg[KeyWriteMode]))
+	}
+
+	if cfg[KeySCD2ValidFrom] != "" {
+		config.SCD2ValidFrom = strings.ToUpper(cfg[KeySCD2ValidFrom])
+	}
+
+	if cfg[KeySCD2ValidTo] != "" {
+		config.SCD2ValidTo = strings.ToUpper(cfg[KeySCD2ValidTo])
+	}
+
+	if cfg[KeySCD2Current] != "" {
+		config.SCD2Current = strings.ToUpper(cfg[KeySCD2Current])
+	}
+
+	config.AuditColumns, err = parseAuditColumns(cfg)
+	if err != nil {
+		return Destination{}, err
+	}
+
+	config.ColumnMapping, err = parseColumnMapping(cfg)
+	if err != nil {
+		return Destination{}, err
+	}
+
+	config.ColumnOverflowPolicies, err = parseColumnOverflowPolicies(cfg)
+	if err != nil {
+		return Destination{}, err
+	}
+
+	config.PartialUpdate, err = parseBool(cfg, KeyPartialUpdate)
+	if err != nil {
+		return Destination{}, err
+	}
+
+	config.KeepMissingFields, err = parseBool(cfg, KeyKeepMissingFields)
+	if err != nil {
+		return Destination{}, err
+	}
+
+	config.Flatten, err = parseBool(cfg, KeyFlatten)
+	if err != nil {
+		return Destination{}, err
+	}
+
+	config.AutoCreateTable, err = parseBool(cfg, KeyAutoCreateTable)
+	if err != nil {
+		return Destination{}, err
+	}
+
+	if cfg[KeyLOBMaxInlineSize] != "" {
+		config.LOBMaxInlineSize, err = strconv.Atoi(cfg[KeyLOBMaxInlineSize])
+		if err != nil {
+			return Destination{}, fmt.Errorf("parse %q: %w", KeyLOBMaxInlineSize, err)
+		}
+	}
+
+	if cfg[KeyRetryMaxAttempts] != "" {
+		config.RetryMaxAttempts, err = strconv.Atoi(cfg[KeyRetryMaxAttempts])
+		if err != nil {
+			return Destination{}, fmt.Errorf("parse %q: %w", KeyRetryMaxAttempts, err)
+		}
+	}
+
+	if cfg[KeyReconnectMaxAttempts] != "" {
+		config.ReconnectMaxAttempts, err = strconv.Atoi(cfg[KeyReconnectMaxAttempts])
+		if err != nil {
+			return Destination{}, fmt.Errorf("parse %q: %w", KeyReconnectMaxAttempts, err)
+		}
+	}
+
+	for key, value := range map[string]*int{
+		KeyPoolMaxOpenConns: &config.PoolMaxOpenConns,
+		KeyPoolMaxIdleConns: &config.PoolMaxIdleConns,
+	} {
+		if cfg[key] == "" {
+			continue
+		}
+
+		if *value, err = strconv.Atoi(cfg[key]); err != nil {
+			return Destination{}, fmt.Errorf("parse %q: %w", key, err)
+		}
+	}
+
+	if cfg[KeyLockTimeout] != "" {
+		lockTimeout, err := strconv.Atoi(cfg[KeyLockTimeout])
+		if err != nil {
+			return Destination{}, fmt.Errorf("parse %q: %w", KeyLockTimeout, err)
+		}
+
+		if lockTimeout < minLockTimeout || lockTimeout > maxLockTimeout {
+			return Destination{}, fmt.Errorf("%q must be between %d and %d", KeyLockTimeout, minLockTimeout, maxLockTimeout)
+		}
+
+		config.LockTimeout = &lockTimeout
+	}
+
+	for key, value := range map[string]*time.Duration{
+		KeyRetryInitialBackoff: &config.RetryInitialBackoff,
+		KeyRetryMaxBackoff:     &config.RetryMaxBackoff,
+		KeyRetryMaxElapsedTime: &config.RetryMaxElapsedTime,
+		KeyPoolConnMaxLifetime: &config.PoolConnMaxLifetime,
+		KeyPoolConnMaxIdleTime: &config.PoolConnMaxIdleTime,
+		KeyQueryTimeout:        &config.QueryTimeout,
+	} {
+		if *value, err = parseDuration(cfg, key, *value); err != nil {
+			return Destination{}, err
+		}
+	}
+
+	if config.RetryMaxBackoff < config.RetryInitialBackoff {
+		return Destination{}, fmt.Errorf("%q must not be less than %q", KeyRetryMaxBackoff, KeyRetryInitialBackoff)
+	}
+
+	// appended rows can't be matched by their keys, so the remaining chunks can't be appended to them.
+	if config.WriteMode == WriteModeAppend && config.LOBMaxInlineSize > 0 && config.LOBPolicy == LOBChunked {
+		return Destination{}, fmt.Errorf("%q: %q policy is not supported by the %q write mode",
+			KeyLOBPolicy, LOBChunked, WriteModeAppend)
+	}
+
+	// the append mode doesn't match rows, so tables without a key are fine.
+	var except []string
+	if config.WriteMode == WriteModeAppend && config.Key == "" {
+		except = append(except, "Config.Key")
+	}
+
+	if err = validator.ValidateExcept(&config, except...); err != nil {
+		return Destination{}, fmt.Errorf("validate config: %w", err)
+	}
+
+	return config, nil
+}
+
+// parseBool parses a boolean value of the key, an empty value is parsed as false.
+func parseBool(cfg map[string]string, key string) (bool, error) {
+	if cfg[key] == "" {
+		return false, nil
+	}
+
+	value, err := strconv.ParseBool(cfg[key])
+	if err != nil {
+		return false, fmt.Errorf("parse %q: %w", key, err)
+	}
+
+	return value, nil
+}
+
+// parseDuration parses a non-negative duration value of the key, e.g. "500ms",
+// an empty value is parsed as the default one.
+func parseDuration(cfg map[string]string, key string, defaultValue time.Duration) (time.Duration, error) {
+	if cfg[key] == "" {
+		return defaultValue, nil
+	}
+
+	value, err := time.ParseDuration(cfg[key])
+	if err != nil {
+		return 0, fmt.Errorf("parse %q: %w", key, err)
+	}
+
+	if value < 0 {
+		return 0, fmt.Errorf("%q must not be negative", key)
+	}
+
+	return value, nil
+}
+
+// parseAuditColumns parses keys with the KeyPrefixAuditColumns prefix into a map of audit columns and their sources.
+func parseAuditColumns(cfg map[string]string) (map[string]string, error) {
+	var auditColumns map[string]string
+
+	for key, source := range cfg {
+		if !strings.HasPrefix(key, KeyPrefixAuditColumns) {
+			continue
+		}
+
+		column := strings.ToUpper(strings.TrimPrefix(key, KeyPrefixAuditColumns))
+		if column == "" || len(column) > maxColumnLength {
+			return nil, fmt.Errorf("%q: invalid audit column name", key)
+		}
+
+		switch {
+		case source == AuditSourceOperation, source == AuditSourcePosition,
+			source == AuditSourceCreatedAt, source == AuditSourceReadAt:
+		case strings.HasPrefix(source, AuditSourcePrefixMetadata) && len(source) > len(AuditSourcePrefixMetadata):
+		default:
+			return nil, fmt.Errorf("%q: invalid audit column source %q", key, source)
+		}
+
+		if auditColumns == nil {
+			auditColumns = make(map[string]string)
+		}
+
+		auditColumns[column] = source
+	}
+
+	return auditColumns, nil
+}
+
+// parseColumnMapping parses keys with the KeyPrefixColumnMapping prefix into a map of field paths and column names.
+func parseColumnMapping(cfg map[string]string) (map[string]string, error) {
+	var columnMapping map[string]string
+
+	for key, column := range cfg {
+		if !strings.HasPrefix(key, KeyPrefixColumnMapping) {
+			continue
+		}
+
+		field := strings.TrimPrefix(key, KeyPrefixColumnMapping)
+		if field == "" {
+			return nil, fmt.Errorf("%q: field must be set", key)
+		}
+
+		if column == "" || len(column) > maxColumnLength {
+			return nil, fmt.Errorf("%q: invalid column name %q", key, column)
+		}
+
+		if columnMapping == nil {
+			columnMapping = make(map[string]string)
+		}
+
+		columnMapping[field] = strings.ToUpper(column)
+	}
+
+	return columnMapping, nil
+}
+
+// parseColumnOverflowPolicies parses keys with the KeyPrefixOverflowPolicy prefix into a map of columns
+// and their overflow policies.
+func parseColumnOverflowPolicies(cfg map[string]string) (map[string]coltypes.OverflowPolicy, error) {
+	var policies map[string]coltypes.OverflowPolicy
+
+	for key, value := range cfg {
+		if !strings.HasPrefix(key, KeyPrefixOverflowPolicy) {
+			continue
+		}
+
+		column := strings.ToUpper(strings.TrimPrefix(key, KeyPrefixOverflowPolicy))
+		if column == "" || len(column) > maxColumnLength {
+			return nil, fmt.Errorf("%q: invalid column name", key)
+		}
+
+		policy := coltypes.OverflowPolicy(strings.ToLower(value))
+
+		switch policy {
+		case coltypes.OverflowFail, coltypes.OverflowTruncate, coltypes.OverflowNull:
+		default:
+			return nil, fmt.Errorf("%q: invalid overflow policy %q", key, value)
+		}
+
+		if policies == nil {
+			policies = make(map[string]coltypes.OverflowPolicy)
+		}
+
+		policies[column] = policy
+	}
+
+	return policies, nil
+}
+
+// parseList splits a comma-separated list into trimmed non-empty items.
+func parseList(value string) []string {
+	var items []string
+
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
@@ -0,0 +1,602 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/conduitio-labs/conduit-connector-db2/coltypes"
+)
+
+const testConnection = "HOSTNAME=localhost;DATABASE=testdb;PORT=50000;UID=DB2INST1;PWD=pwd"
+
+// testDestination returns a Destination with the test connection, the CLIENTS table, the ID key
+// and default values, modified by the provided function.
+func testDestination(modify func(*Destination)) Destination {
+	destination := Destination{
+		Config: Config{
+			Connection: testConnection,
+			Table:      "CLIENTS",
+			Key:        "ID",
+		},
+		WriteMode:            WriteModeUpsert,
+		SCD2ValidFrom:        DefaultSCD2ValidFrom,
+		SCD2ValidTo:          DefaultSCD2ValidTo,
+		SCD2Current:          DefaultSCD2Current,
+		FlattenSeparator:     DefaultFlattenSeparator,
+		UnknownColumns:       UnknownColumnsFail,
+		TimeZone:             DefaultTimeZone,
+		EpochUnit:            DefaultEpochUnit,
+		OverflowPolicy:       coltypes.OverflowFail,
+		BinaryEncoding:       coltypes.BinaryRaw,
+		XMLRootElement:       DefaultXMLRootElement,
+		LOBPolicy:            LOBChunked,
+		RetryMaxAttempts:     DefaultRetryMaxAttempts,
+		RetryInitialBackoff:  DefaultRetryInitialBackoff,
+		RetryMaxBackoff:      DefaultRetryMaxBackoff,
+		RetryMaxElapsedTime:  DefaultRetryMaxElapsedTime,
+		ReconnectMaxAttempts: DefaultReconnectMaxAttempts,
+		PoolMaxIdleConns:     DefaultPoolMaxIdleConns,
+	}
+
+	if modify != nil {
+		modify(&destination)
+	}
+
+	return destination
+}
+
+func TestParseDestination(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		cfg     map[string]string
+		want    Destination
+		wantErr bool
+	}{
+		{
+			name: "success, default values",
+			cfg: map[string]string{
+				KeyConnection: testConnection,
+				KeyTable:      "clients",
+				KeyPrimaryKey: "id",
+			},
+			want: testDestination(nil),
+		},
+		{
+			name: "success, append mode without key",
+			cfg: map[string]string{
+				KeyConnection: testConnection,
+				KeyTable:      "CLIENTS",
+				KeyWriteMode:  "Append",
+			},
+			want: testDestination(func(d *Destination) {
+				d.Key = ""
+				d.WriteMode = WriteModeAppend
+			}),
+		},
+		{
+			name: "success, version column",
+			cfg: map[string]string{
+				KeyConnection:    testConnection,
+				KeyTable:         "CLIENTS",
+				KeyPrimaryKey:    "ID",
+				KeyVersionColumn: "updated_at",
+			},
+			want: testDestination(func(d *Destination) {
+				d.VersionColumn = "UPDATED_AT"
+			}),
+		},
+		{
+			name: "success, partial update",
+			cfg: map[string]string{
+				KeyConnection:        testConnection,
+				KeyTable:             "CLIENTS",
+				KeyPrimaryKey:        "ID",
+				KeyPartialUpdate:     "true",
+				KeyKeepMissingFields: "true",
+			},
+			want: testDestination(func(d *Destination) {
+				d.PartialUpdate = true
+				d.KeepMissingFields = true
+			}),
+		},
+		{
+			name: "success, scd2 mode with custom columns",
+			cfg: map[string]string{
+				KeyConnection:    testConnection,
+				KeyTable:         "CLIENTS",
+				KeyPrimaryKey:    "ID",
+				KeyWriteMode:     "scd2",
+				KeySCD2ValidFrom: "start_date",
+				KeySCD2ValidTo:   "end_date",
+			},
+			want: testDestination(func(d *Destination) {
+				d.WriteMode = WriteModeSCD2
+				d.SCD2ValidFrom = "START_DATE"
+				d.SCD2ValidTo = "END_DATE"
+			}),
+		},
+		{
+			name: "success, audit columns",
+			cfg: map[string]string{
+				KeyConnection:                      testConnection,
+				KeyTable:                           "CLIENTS",
+				KeyPrimaryKey:                      "ID",
+				KeyPrefixAuditColumns + "sync_op":  "operation",
+				KeyPrefixAuditColumns + "SYNC_TS":  "metadata.opencdc.readAt",
+				KeyPrefixAuditColumns + "SYNC_POS": "position",
+			},
+			want: testDestination(func(d *Destination) {
+				d.AuditColumns = map[string]string{
+					"SYNC_OP":  AuditSourceOperation,
+					"SYNC_TS":  "metadata.opencdc.readAt",
+					"SYNC_POS": AuditSourcePosition,
+				}
+			}),
+		},
+		{
+			name: "success, column mapping",
+			cfg: map[string]string{
+				KeyConnection:                           testConnection,
+				KeyTable:                                "CLIENTS",
+				KeyPrimaryKey:                           "ID",
+				KeyPrefixColumnMapping + "address.city": "city",
+				KeyPrefixColumnMapping + "fullName":     "NAME",
+				KeyIncludeFields:                        "id, fullName,address.city",
+				KeyExcludeFields:                        "password",
+			},
+			want: testDestination(func(d *Destination) {
+				d.ColumnMapping = map[string]string{
+					"address.city": "CITY",
+					"fullName":     "NAME",
+				}
+				d.IncludeFields = []string{"id", "fullName", "address.city"}
+				d.ExcludeFields = []string{"password"}
+			}),
+		},
+		{
+			name: "success, flatten",
+			cfg: map[string]string{
+				KeyConnection:       testConnection,
+				KeyTable:            "CLIENTS",
+				KeyPrimaryKey:       "ID",
+				KeyFlatten:          "true",
+				KeyFlattenSeparator: "__",
+			},
+			want: testDestination(func(d *Destination) {
+				d.Flatten = true
+				d.FlattenSeparator = "__"
+			}),
+		},
+		{
+			name: "success, auto create table",
+			cfg: map[string]string{
+				KeyConnection:      testConnection,
+				KeyTable:           "CLIENTS",
+				KeyPrimaryKey:      "ID",
+				KeyAutoCreateTable: "true",
+			},
+			want: testDestination(func(d *Destination) {
+				d.AutoCreateTable = true
+			}),
+		},
+		{
+			name: "success, ignore unknown columns",
+			cfg: map[string]string{
+				KeyConnection:     testConnection,
+				KeyTable:          "CLIENTS",
+				KeyPrimaryKey:     "ID",
+				KeyUnknownColumns: "Ignore",
+			},
+			want: testDestination(func(d *Destination) {
+				d.UnknownColumns = UnknownColumnsIgnore
+			}),
+		},
+		{
+			name: "success, evolve unknown columns",
+			cfg: map[string]string{
+				KeyConnection:     testConnection,
+				KeyTable:          "CLIENTS",
+				KeyPrimaryKey:     "ID",
+				KeyUnknownColumns: "evolve",
+			},
+			want: testDestination(func(d *Destination) {
+				d.UnknownColumns = UnknownColumnsEvolve
+			}),
+		},
+		{
+			name: "success, time options",
+			cfg: map[string]string{
+				KeyConnection:  testConnection,
+				KeyTable:       "CLIENTS",
+				KeyPrimaryKey:  "ID",
+				KeyTimeZone:    "Europe/Kyiv",
+				KeyTimeLayouts: "02.01.2006 15:04 | Mon, 02 Jan 2006",
+				KeyEpochUnit:   "S",
+			},
+			want: testDestination(func(d *Destination) {
+				d.TimeZone = "Europe/Kyiv"
+				d.TimeLayouts = []string{"02.01.2006 15:04", "Mon, 02 Jan 2006"}
+				d.EpochUnit = EpochUnitSeconds
+			}),
+		},
+		{
+			name: "success, overflow policies",
+			cfg: map[string]string{
+				KeyConnection:                       testConnection,
+				KeyTable:                            "CLIENTS",
+				KeyPrimaryKey:                       "ID",
+				KeyOverflowPolicy:                   "null",
+				KeyPrefixOverflowPolicy + "comment": "Truncate",
+			},
+			want: testDestination(func(d *Destination) {
+				d.OverflowPolicy = coltypes.OverflowNull
+				d.ColumnOverflowPolicies = map[string]coltypes.OverflowPolicy{"COMMENT": coltypes.OverflowTruncate}
+			}),
+		},
+		{
+			name: "fail, invalid column overflow policy",
+			cfg: map[string]string{
+				KeyConnection:                       testConnection,
+				KeyTable:                            "CLIENTS",
+				KeyPrimaryKey:                       "ID",
+				KeyPrefixOverflowPolicy + "COMMENT": "round",
+			},
+			wantErr: true,
+		},
+		{
+			name: "success, binary encoding",
+			cfg: map[string]string{
+				KeyConnection:     testConnection,
+				KeyTable:          "CLIENTS",
+				KeyPrimaryKey:     "ID",
+				KeyBinaryEncoding: "Base64",
+			},
+			want: testDestination(func(d *Destination) {
+				d.BinaryEncoding = coltypes.BinaryBase64
+			}),
+		},
+		{
+			name: "fail, invalid binary encoding",
+			cfg: map[string]string{
+				KeyConnection:     testConnection,
+				KeyTable:          "CLIENTS",
+				KeyPrimaryKey:     "ID",
+				KeyBinaryEncoding: "base32",
+			},
+			wantErr: true,
+		},
+		{
+			name: "success, xml root element",
+			cfg: map[string]string{
+				KeyConnection:     testConnection,
+				KeyTable:          "CLIENTS",
+				KeyPrimaryKey:     "ID",
+				KeyXMLRootElement: "client",
+			},
+			want: testDestination(func(d *Destination) {
+				d.XMLRootElement = "client"
+			}),
+		},
+		{
+			name: "success, lob options",
+			cfg: map[string]string{
+				KeyConnection:         testConnection,
+				KeyTable:              "CLIENTS",
+				KeyPrimaryKey:         "ID",
+				KeyLOBMaxInlineSize:   "1048576",
+				KeyLOBPolicy:          "Truncate",
+				KeyLOBTruncatedColumn: "lob_truncated",
+			},
+			want: testDestination(func(d *Destination) {
+				d.LOBMaxInlineSize = 1048576
+				d.LOBPolicy = LOBTruncate
+				d.LOBTruncatedColumn = "LOB_TRUNCATED"
+			}),
+		},
+		{
+			name: "fail, invalid lob policy",
+			cfg: map[string]string{
+				KeyConnection: testConnection,
+				KeyTable:      "CLIENTS",
+				KeyPrimaryKey: "ID",
+				KeyLOBPolicy:  "stream",
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail, negative lob max inline size",
+			cfg: map[string]string{
+				KeyConnection:       testConnection,
+				KeyTable:            "CLIENTS",
+				KeyPrimaryKey:       "ID",
+				KeyLOBMaxInlineSize: "-1",
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail, chunked lobs in append mode",
+			cfg: map[string]string{
+				KeyConnection:       testConnection,
+				KeyTable:            "CLIENTS",
+				KeyWriteMode:        "append",
+				KeyLOBMaxInlineSize: "1024",
+			},
+			wantErr: true,
+		},
+		{
+			name: "success, retry options",
+			cfg: map[string]string{
+				KeyConnection:          testConnection,
+				KeyTable:               "CLIENTS",
+				KeyPrimaryKey:          "ID",
+				KeyRetryMaxAttempts:    "1",
+				KeyRetryInitialBackoff: "50ms",
+				KeyRetryMaxBackoff:     "2s",
+				KeyRetryMaxElapsedTime: "0",
+			},
+			want: testDestination(func(d *Destination) {
+				d.RetryMaxAttempts = 1
+				d.RetryInitialBackoff = 50 * time.Millisecond
+				d.RetryMaxBackoff = 2 * time.Second
+				d.RetryMaxElapsedTime = 0
+			}),
+		},
+		{
+			name: "success, reconnects disabled",
+			cfg: map[string]string{
+				KeyConnection:           testConnection,
+				KeyTable:                "CLIENTS",
+				KeyPrimaryKey:           "ID",
+				KeyReconnectMaxAttempts: "0",
+			},
+			want: testDestination(func(d *Destination) {
+				d.ReconnectMaxAttempts = 0
+			}),
+		},
+		{
+			name: "fail, negative reconnect max attempts",
+			cfg: map[string]string{
+				KeyConnection:           testConnection,
+				KeyTable:                "CLIENTS",
+				KeyPrimaryKey:           "ID",
+				KeyReconnectMaxAttempts: "-1",
+			},
+			wantErr: true,
+		},
+		{
+			name: "success, dead-letter table",
+			cfg: map[string]string{
+				KeyConnection:      testConnection,
+				KeyTable:           "CLIENTS",
+				KeyPrimaryKey:      "ID",
+				KeyDeadLetterTable: "clients_dlq",
+			},
+			want: testDestination(func(d *Destination) {
+				d.DeadLetterTable = "CLIENTS_DLQ"
+			}),
+		},
+		{
+			name: "success, pool and timeouts",
+			cfg: map[string]string{
+				KeyConnection:          testConnection,
+				KeyTable:               "CLIENTS",
+				KeyPrimaryKey:          "ID",
+				KeyPoolMaxOpenConns:    "8",
+				KeyPoolMaxIdleConns:    "4",
+				KeyPoolConnMaxLifetime: "30m",
+				KeyPoolConnMaxIdleTime: "5m",
+				KeyQueryTimeout:        "30s",
+				KeyLockTimeout:         "10",
+			},
+			want: testDestination(func(d *Destination) {
+				lockTimeout := 10
+
+				d.PoolMaxOpenConns = 8
+				d.PoolMaxIdleConns = 4
+				d.PoolConnMaxLifetime = 30 * time.Minute
+				d.PoolConnMaxIdleTime = 5 * time.Minute
+				d.QueryTimeout = 30 * time.Second
+				d.LockTimeout = &lockTimeout
+			}),
+		},
+		{
+			name: "success, lock timeout without limit",
+			cfg: map[string]string{
+				KeyConnection:  testConnection,
+				KeyTable:       "CLIENTS",
+				KeyPrimaryKey:  "ID",
+				KeyLockTimeout: "-1",
+			},
+			want: testDestination(func(d *Destination) {
+				lockTimeout := -1
+
+				d.LockTimeout = &lockTimeout
+			}),
+		},
+		{
+			name: "fail, lock timeout out of range",
+			cfg: map[string]string{
+				KeyConnection:  testConnection,
+				KeyTable:       "CLIENTS",
+				KeyPrimaryKey:  "ID",
+				KeyLockTimeout: "32768",
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail, negative pool max idle connections",
+			cfg: map[string]string{
+				KeyConnection:       testConnection,
+				KeyTable:            "CLIENTS",
+				KeyPrimaryKey:       "ID",
+				KeyPoolMaxIdleConns: "-1",
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail, invalid query timeout",
+			cfg: map[string]string{
+				KeyConnection:   testConnection,
+				KeyTable:        "CLIENTS",
+				KeyPrimaryKey:   "ID",
+				KeyQueryTimeout: "30",
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail, zero retry max attempts",
+			cfg: map[string]string{
+				KeyConnection:       testConnection,
+				KeyTable:            "CLIENTS",
+				KeyPrimaryKey:       "ID",
+				KeyRetryMaxAttempts: "0",
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail, invalid retry backoff",
+			cfg: map[string]string{
+				KeyConnection:          testConnection,
+				KeyTable:               "CLIENTS",
+				KeyPrimaryKey:          "ID",
+				KeyRetryInitialBackoff: "100",
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail, negative retry max elapsed time",
+			cfg: map[string]string{
+				KeyConnection:          testConnection,
+				KeyTable:               "CLIENTS",
+				KeyPrimaryKey:          "ID",
+				KeyRetryMaxElapsedTime: "-1m",
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail, retry max backoff less than initial backoff",
+			cfg: map[string]string{
+				KeyConnection:          testConnection,
+				KeyTable:               "CLIENTS",
+				KeyPrimaryKey:          "ID",
+				KeyRetryInitialBackoff: "10s",
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail, invalid time zone",
+			cfg: map[string]string{
+				KeyConnection: testConnection,
+				KeyTable:      "CLIENTS",
+				KeyPrimaryKey: "ID",
+				KeyTimeZone:   "Mars/Olympus",
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail, invalid epoch unit",
+			cfg: map[string]string{
+				KeyConnection: testConnection,
+				KeyTable:      "CLIENTS",
+				KeyPrimaryKey: "ID",
+				KeyEpochUnit:  "min",
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail, invalid unknown columns policy",
+			cfg: map[string]string{
+				KeyConnection:     testConnection,
+				KeyTable:          "CLIENTS",
+				KeyPrimaryKey:     "ID",
+				KeyUnknownColumns: "skip",
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail, empty mapped column",
+			cfg: map[string]string{
+				KeyConnection:                       testConnection,
+				KeyTable:                            "CLIENTS",
+				KeyPrimaryKey:                       "ID",
+				KeyPrefixColumnMapping + "fullName": "",
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail, invalid audit column source",
+			cfg: map[string]string{
+				KeyConnection:                     testConnection,
+				KeyTable:                          "CLIENTS",
+				KeyPrimaryKey:                     "ID",
+				KeyPrefixAuditColumns + "SYNC_OP": "metadata.",
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail, invalid partial update",
+			cfg: map[string]string{
+				KeyConnection:    testConnection,
+				KeyTable:         "CLIENTS",
+				KeyPrimaryKey:    "ID",
+				KeyPartialUpdate: "yes",
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail, upsert mode without key",
+			cfg: map[string]string{
+				KeyConnection: testConnection,
+				KeyTable:      "CLIENTS",
+				KeyWriteMode:  "upsert",
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail, invalid write mode",
+			cfg: map[string]string{
+				KeyConnection: testConnection,
+				KeyTable:      "CLIENTS",
+				KeyPrimaryKey: "ID",
+				KeyWriteMode:  "replace",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseDestination(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseDestination() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDestination() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
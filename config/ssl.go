@@ -0,0 +1,183 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	KeySSLEnabled           string = "ssl.enabled"
+	KeySSLServerCertificate string = "ssl.serverCertificate"
+	KeySSLClientKeystoreDB  string = "ssl.clientKeystoreDB"
+	KeySSLClientKeystash    string = "ssl.clientKeystash"
+	KeySSLClientLabel       string = "ssl.clientLabel"
+	// KeySSLCertificateAuth is a key of the flag that enables authentication by the client certificate
+	// instead of the user and password.
+	KeySSLCertificateAuth string = "ssl.certificateAuthentication"
+)
+
+// SSL related CLI keywords of the connection string.
+const (
+	keywordSecurity            = "SECURITY"
+	keywordSSLServerCert       = "SSLSERVERCERTIFICATE"
+	keywordSSLClientKeystoreDB = "SSLCLIENTKEYSTOREDB"
+	keywordSSLClientKeystash   = "SSLCLIENTKEYSTASH"
+	keywordSSLClientLabel      = "SSLCLIENTLABEL"
+	keywordAuthentication      = "AUTHENTICATION"
+
+	securitySSL               = "SSL"
+	authenticationCertificate = "CERTIFICATE"
+)
+
+// sslKeys contains the keys of the SSL parameters.
+var sslKeys = []string{
+	KeySSLEnabled, KeySSLServerCertificate, KeySSLClientKeystoreDB,
+	KeySSLClientKeystash, KeySSLClientLabel, KeySSLCertificateAuth,
+}
+
+// parseSSL returns the SSL keywords of the connection string. The server is verified by the certificate
+// or by the client keystore, the files are checked to exist and the certificate to parse.
+func parseSSL(cfg map[string]string) ([][2]string, error) {
+	enabled, err := parseBool(cfg, KeySSLEnabled)
+	if err != nil {
+		return nil, err
+	}
+
+	if !enabled {
+		for _, key := range sslKeys[1:] {
+			if cfg[key] != "" {
+				return nil, fmt.Errorf("%q requires %q", key, KeySSLEnabled)
+			}
+		}
+
+		return nil, nil
+	}
+
+	certificateAuth, err := parseBool(cfg, KeySSLCertificateAuth)
+	if err != nil {
+		return nil, err
+	}
+
+	serverCertificate, keystoreDB, keystash := cfg[KeySSLServerCertificate], cfg[KeySSLClientKeystoreDB],
+		cfg[KeySSLClientKeystash]
+
+	switch {
+	case serverCertificate == "" && keystoreDB == "":
+		return nil, fmt.Errorf("either %q or %q value must be set", KeySSLServerCertificate, KeySSLClientKeystoreDB)
+	case (keystoreDB == "") != (keystash == ""):
+		return nil, fmt.Errorf("%q and %q values must be set together", KeySSLClientKeystoreDB, KeySSLClientKeystash)
+	case certificateAuth && keystoreDB == "":
+		return nil, fmt.Errorf("%q requires %q", KeySSLCertificateAuth, KeySSLClientKeystoreDB)
+	case cfg[KeySSLClientLabel] != "" && keystoreDB == "":
+		return nil, fmt.Errorf("%q requires %q", KeySSLClientLabel, KeySSLClientKeystoreDB)
+	case certificateAuth && (cfg[KeyUser] != "" || cfg[KeyPassword] != ""):
+		return nil, fmt.Errorf("%q and %q must not be set with %q", KeyUser, KeyPassword, KeySSLCertificateAuth)
+	}
+
+	if serverCertificate != "" {
+		if err = checkCertificate(serverCertificate); err != nil {
+			return nil, fmt.Errorf("%q: %w", KeySSLServerCertificate, err)
+		}
+	}
+
+	for key, path := range map[string]string{KeySSLClientKeystoreDB: keystoreDB, KeySSLClientKeystash: keystash} {
+		if path == "" {
+			continue
+		}
+
+		if err = checkFile(path); err != nil {
+			return nil, fmt.Errorf("%q: %w", key, err)
+		}
+	}
+
+	keywords := [][2]string{
+		{keywordSecurity, securitySSL},
+		{keywordSSLServerCert, serverCertificate},
+		{keywordSSLClientKeystoreDB, keystoreDB},
+		{keywordSSLClientKeystash, keystash},
+		{keywordSSLClientLabel, cfg[KeySSLClientLabel]},
+	}
+
+	if certificateAuth {
+		keywords = append(keywords, [2]string{keywordAuthentication, authenticationCertificate})
+	}
+
+	return keywords, nil
+}
+
+// isSSLKeyword reports whether the keyword is set by the SSL parameters.
+func isSSLKeyword(keyword string) bool {
+	switch keyword {
+	case keywordSecurity, keywordAuthentication:
+		return true
+	default:
+		return strings.HasPrefix(keyword, "SSL")
+	}
+}
+
+// checkCertificate returns an error if the file doesn't contain PEM encoded X.509 certificates.
+func checkCertificate(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read certificate: %w", err)
+	}
+
+	var certificates int
+
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		if _, err = x509.ParseCertificate(block.Bytes); err != nil {
+			return fmt.Errorf("parse certificate: %w", err)
+		}
+
+		certificates++
+	}
+
+	if certificates == 0 {
+		return errors.New("no PEM encoded certificates found")
+	}
+
+	return nil
+}
+
+// checkFile returns an error if the path is not a readable non-empty file. Keystores are
+// in the GSKit format, so their content is left to the driver.
+func checkFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("stat file: %w", err)
+	}
+
+	if !info.Mode().IsRegular() || info.Size() == 0 {
+		return fmt.Errorf("%s is not a non-empty file", path)
+	}
+
+	return nil
+}
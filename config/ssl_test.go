@@ -0,0 +1,214 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestFile writes the data to the file in the directory and returns its path.
+func writeTestFile(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+
+	return path
+}
+
+// testCertificate returns a PEM encoded self-signed certificate.
+func testCertificate(t *testing.T) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "db2.example.com"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestParseConnection_SSL(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	certificate := writeTestFile(t, dir, "server.arm", testCertificate(t))
+	invalidCertificate := writeTestFile(t, dir, "invalid.arm",
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("invalid")}))
+	notCertificate := writeTestFile(t, dir, "key.pem", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY"}))
+	keystoreDB := writeTestFile(t, dir, "client.kdb", []byte("keystore"))
+	keystash := writeTestFile(t, dir, "client.sth", []byte("stash"))
+	emptyKeystash := writeTestFile(t, dir, "empty.sth", nil)
+
+	base := map[string]string{KeyHost: "localhost", KeyDatabase: "testdb", KeyPort: "50001"}
+
+	// withBase returns the base parameters with the SSL ones.
+	withBase := func(cfg map[string]string) map[string]string {
+		for key, value := range base {
+			cfg[key] = value
+		}
+
+		return cfg
+	}
+
+	tests := []struct {
+		name    string
+		cfg     map[string]string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "server certificate",
+			cfg: withBase(map[string]string{
+				KeySSLEnabled: "true", KeySSLServerCertificate: certificate, KeyUser: "DB2INST1", KeyPassword: "pwd",
+			}),
+			want: "HOSTNAME=localhost;PORT=50001;DATABASE=testdb;PROTOCOL=TCPIP;UID=DB2INST1;PWD=pwd;" +
+				"SECURITY=SSL;SSLSERVERCERTIFICATE=" + certificate,
+		},
+		{
+			name: "client certificate authentication",
+			cfg: withBase(map[string]string{
+				KeySSLEnabled:          "true",
+				KeySSLClientKeystoreDB: keystoreDB,
+				KeySSLClientKeystash:   keystash,
+				KeySSLClientLabel:      "conduit",
+				KeySSLCertificateAuth:  "true",
+				KeyConnectionParams:    "PROGRAMNAME=conduit",
+			}),
+			want: "HOSTNAME=localhost;PORT=50001;DATABASE=testdb;PROTOCOL=TCPIP;SECURITY=SSL;" +
+				"SSLCLIENTKEYSTOREDB=" + keystoreDB + ";SSLCLIENTKEYSTASH=" + keystash + ";SSLCLIENTLABEL=conduit;" +
+				"AUTHENTICATION=CERTIFICATE;PROGRAMNAME=conduit",
+		},
+		{
+			name: "disabled",
+			cfg:  withBase(map[string]string{KeySSLEnabled: "false"}),
+			want: "HOSTNAME=localhost;PORT=50001;DATABASE=testdb;PROTOCOL=TCPIP",
+		},
+		{
+			name:    "parameters without ssl enabled",
+			cfg:     withBase(map[string]string{KeySSLServerCertificate: certificate}),
+			wantErr: true,
+		},
+		{
+			name:    "with raw connection string",
+			cfg:     map[string]string{KeyConnection: testConnection, KeySSLEnabled: "true"},
+			wantErr: true,
+		},
+		{
+			name:    "no server certificate or keystore",
+			cfg:     withBase(map[string]string{KeySSLEnabled: "true"}),
+			wantErr: true,
+		},
+		{
+			name:    "missing server certificate",
+			cfg:     withBase(map[string]string{KeySSLEnabled: "true", KeySSLServerCertificate: dir + "/missing.arm"}),
+			wantErr: true,
+		},
+		{
+			name:    "invalid server certificate",
+			cfg:     withBase(map[string]string{KeySSLEnabled: "true", KeySSLServerCertificate: invalidCertificate}),
+			wantErr: true,
+		},
+		{
+			name:    "server certificate file without certificates",
+			cfg:     withBase(map[string]string{KeySSLEnabled: "true", KeySSLServerCertificate: notCertificate}),
+			wantErr: true,
+		},
+		{
+			name:    "keystore without stash",
+			cfg:     withBase(map[string]string{KeySSLEnabled: "true", KeySSLClientKeystoreDB: keystoreDB}),
+			wantErr: true,
+		},
+		{
+			name: "empty stash",
+			cfg: withBase(map[string]string{
+				KeySSLEnabled: "true", KeySSLClientKeystoreDB: keystoreDB, KeySSLClientKeystash: emptyKeystash,
+			}),
+			wantErr: true,
+		},
+		{
+			name: "certificate authentication without keystore",
+			cfg: withBase(map[string]string{
+				KeySSLEnabled: "true", KeySSLServerCertificate: certificate, KeySSLCertificateAuth: "true",
+			}),
+			wantErr: true,
+		},
+		{
+			name: "certificate authentication with password",
+			cfg: withBase(map[string]string{
+				KeySSLEnabled:          "true",
+				KeySSLClientKeystoreDB: keystoreDB,
+				KeySSLClientKeystash:   keystash,
+				KeySSLCertificateAuth:  "true",
+				KeyUser:                "DB2INST1",
+				KeyPassword:            "pwd",
+			}),
+			wantErr: true,
+		},
+		{
+			name: "ssl keyword in extra keywords",
+			cfg: withBase(map[string]string{
+				KeySSLEnabled:           "true",
+				KeySSLServerCertificate: certificate,
+				KeyConnectionParams:     "SSLServerCertificate=/tmp/other.arm",
+			}),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseConnection(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseConnection() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+
+			if got != tt.want {
+				t.Errorf("parseConnection() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package db2errors implements a classification of DB2 errors by their SQLCODE and SQLSTATE.
+package db2errors
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	db2 "github.com/ibmdb/go_ibm_db"
+)
+
+// NoRecordIndex is a record index of errors that are not related to a particular record.
+const NoRecordIndex = -1
+
+// SQLCODE values of the classified errors.
+const (
+	codeDuplicateKey      = -803
+	codeValueTooLong      = -302
+	codeStringTooLong     = -404
+	codeNumericOverflow   = -406
+	codeArithmeticError   = -802
+	codeDeadlock          = -911
+	codeLockTimeout       = -913
+	codeConnectionFailed  = -1224
+	codeCommunication     = -30081
+	codeConnectionClosed  = -30080
+	codeClientReroute     = -30108
+	codeDatabaseNotActive = -1032
+)
+
+// SQLSTATE values and classes of the classified errors.
+const (
+	stateDuplicateKey     = "23505"
+	stateStringTruncation = "22001"
+	stateNumericOverflow  = "22003"
+	stateDeadlock         = "40001"
+	stateLockTimeout      = "57033"
+	// stateClassConnection is a class of connection exceptions, e.g. 08001 or 08S01.
+	stateClassConnection = "08"
+	// stateClassData is a class of data exceptions, e.g. 22001 or 22007.
+	stateClassData = "22"
+	// stateClassConstraint is a class of integrity constraint violations, e.g. 23502 or 23505.
+	stateClassConstraint = "23"
+)
+
+var (
+	// sqlCodePattern matches message identifiers of DB2 messages, e.g. SQL0911N, N and C messages
+	// have negative SQLCODEs, W messages have positive ones.
+	sqlCodePattern = regexp.MustCompile(`\bSQL(\d{4,5})([NCW])\b`)
+	// sqlStatePattern matches SQLSTATEs in DB2 messages, e.g. SQLSTATE=40001, or in CLI diagnostics, e.g. {40001}.
+	sqlStatePattern = regexp.MustCompile(`SQLSTATE=(\w{5})|\{(\w{5})\}`)
+)
+
+// Error is a DB2 error with its SQLCODE and SQLSTATE, and the table and record it occurred for, if they're known.
+type Error struct {
+	// SQLCode is a DB2 SQLCODE, e.g. -911, it's 0 if it's unknown.
+	SQLCode int
+	// SQLState is a five-character SQLSTATE, e.g. "40001", it's empty if it's unknown.
+	SQLState string
+	// Table is a name of the table the statement was executed for, optional.
+	Table string
+	// RecordIndex is an index of the record in the written batch, or NoRecordIndex.
+	RecordIndex int
+	// Err is the original error.
+	Err error
+}
+
+// Error returns a message of the error. The record index is not a part of the message,
+// since it's set after the error is wrapped by the callers.
+func (e *Error) Error() string {
+	if e.Table != "" {
+		return fmt.Sprintf("table %q, SQLCODE %d, SQLSTATE %s: %v", e.Table, e.SQLCode, e.SQLState, e.Err)
+	}
+
+	return fmt.Sprintf("SQLCODE %d, SQLSTATE %s: %v", e.SQLCode, e.SQLState, e.Err)
+}
+
+// Unwrap returns the original error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Parse returns the DB2 error the err contains. Errors of the go_ibm_db driver are parsed
+// from their first diagnostic record, other errors are parsed from their messages.
+// It returns nil if the err contains neither SQLCODE nor SQLSTATE.
+func Parse(err error) *Error {
+	if err == nil {
+		return nil
+	}
+
+	var db2Err *Error
+	if errors.As(err, &db2Err) {
+		return db2Err
+	}
+
+	var driverErr *db2.Error
+	if errors.As(err, &driverErr) && len(driverErr.Diag) > 0 {
+		return &Error{
+			SQLCode:     driverErr.Diag[0].NativeError,
+			SQLState:    driverErr.Diag[0].State,
+			RecordIndex: NoRecordIndex,
+			Err:         err,
+		}
+	}
+
+	return parseMessage(err)
+}
+
+// parseMessage parses the SQLCODE and SQLSTATE from the message of the err.
+func parseMessage(err error) *Error {
+	result := &Error{RecordIndex: NoRecordIndex, Err: err}
+
+	message := err.Error()
+
+	if match := sqlCodePattern.FindStringSubmatch(message); match != nil {
+		code, _ := strconv.Atoi(match[1])
+		if match[2] != "W" {
+			code = -code
+		}
+
+		result.SQLCode = code
+	}
+
+	if match := sqlStatePattern.FindStringSubmatch(message); match != nil {
+		result.SQLState = match[1] + match[2]
+	}
+
+	if result.SQLCode == 0 && result.SQLState == "" {
+		return nil
+	}
+
+	return result
+}
+
+// WithTable returns the err parsed into a DB2 error with the table set. If the err already contains
+// a DB2 error, its table is set, if it's not set yet, and the err is returned. Other errors are returned as they are.
+func WithTable(err error, table string) error {
+	var db2Err *Error
+	if errors.As(err, &db2Err) {
+		if db2Err.Table == "" {
+			db2Err.Table = table
+		}
+
+		return err
+	}
+
+	if db2Err = Parse(err); db2Err == nil {
+		return err
+	}
+
+	db2Err.Table = table
+
+	return db2Err
+}
+
+// WithRecordIndex sets the record index of the DB2 error the err contains, if the index is not set yet,
+// and returns the err.
+func WithRecordIndex(err error, index int) error {
+	var db2Err *Error
+	if errors.As(err, &db2Err) && db2Err.RecordIndex == NoRecordIndex {
+		db2Err.RecordIndex = index
+	}
+
+	return err
+}
+
+// IsDeadlock reports whether the err is a deadlock or timeout that rolled back the transaction.
+func IsDeadlock(err error) bool {
+	return is(err, []int{codeDeadlock}, []string{stateDeadlock})
+}
+
+// IsLockTimeout reports whether the err is a deadlock or timeout that rolled back only the statement.
+func IsLockTimeout(err error) bool {
+	return is(err, []int{codeLockTimeout}, []string{stateLockTimeout})
+}
+
+// IsDuplicateKey reports whether the err is a violation of a primary key or unique constraint.
+func IsDuplicateKey(err error) bool {
+	return is(err, []int{codeDuplicateKey}, []string{stateDuplicateKey})
+}
+
+// IsTruncation reports whether the err is a value that is too long or out of its column's range.
+func IsTruncation(err error) bool {
+	return is(err,
+		[]int{codeValueTooLong, codeStringTooLong, codeNumericOverflow, codeArithmeticError},
+		[]string{stateStringTruncation, stateNumericOverflow},
+	)
+}
+
+// IsDataError reports whether the err is caused by the written values rather than by the database or
+// the statement: a data exception, e.g. a value that is too long or an invalid datetime, or an integrity
+// constraint violation, e.g. a duplicate key or a NULL in a NOT NULL column.
+func IsDataError(err error) bool {
+	if IsDuplicateKey(err) || IsTruncation(err) {
+		return true
+	}
+
+	db2Err := Parse(err)
+
+	return db2Err != nil &&
+		(strings.HasPrefix(db2Err.SQLState, stateClassData) || strings.HasPrefix(db2Err.SQLState, stateClassConstraint))
+}
+
+// IsConnectionLost reports whether the err is caused by a broken or closed connection to the database.
+func IsConnectionLost(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+
+	if is(err, []int{codeCommunication, codeConnectionClosed, codeConnectionFailed,
+		codeClientReroute, codeDatabaseNotActive}, nil) {
+		return true
+	}
+
+	db2Err := Parse(err)
+
+	return db2Err != nil && strings.HasPrefix(db2Err.SQLState, stateClassConnection)
+}
+
+// IsTransient reports whether the err is a deadlock, a lock timeout or a lost connection,
+// so the failed statement or transaction may succeed if it's retried.
+func IsTransient(err error) bool {
+	return IsDeadlock(err) || IsLockTimeout(err) || IsConnectionLost(err)
+}
+
+// is reports whether the err is a DB2 error with one of the codes or states.
+func is(err error, codes []int, states []string) bool {
+	db2Err := Parse(err)
+	if db2Err == nil {
+		return false
+	}
+
+	for _, code := range codes {
+		if db2Err.SQLCode == code {
+			return true
+		}
+	}
+
+	for _, state := range states {
+		if db2Err.SQLState == state {
+			return true
+		}
+	}
+
+	return false
+}
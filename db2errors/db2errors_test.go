@@ -0,0 +1,233 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db2errors
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+
+	db2 "github.com/ibmdb/go_ibm_db"
+)
+
+// deadlockMessage is a message of the deadlock error returned by the driver.
+const deadlockMessage = "SQLExecute: {40001} [IBM][CLI Driver][DB2/LINUXX8664] SQL0911N  " +
+	"The current transaction has been deleted or rolled back because of a deadlock or timeout.  " +
+	"Reason code \"2\".  SQLSTATE=40001\n"
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		err       error
+		wantCode  int
+		wantState string
+		wantNil   bool
+	}{
+		{
+			name: "driver error",
+			err: fmt.Errorf("exec upsert: %w", &db2.Error{
+				APIName: "SQLExecute",
+				Diag:    []db2.DiagRecord{{State: "23505", NativeError: -803, Message: "SQL0803N"}},
+			}),
+			wantCode:  -803,
+			wantState: "23505",
+		},
+		{
+			name:      "message",
+			err:       errors.New(deadlockMessage),
+			wantCode:  -911,
+			wantState: "40001",
+		},
+		{
+			name:      "warning message",
+			err:       errors.New("SQL0445W  Value has been truncated.  SQLSTATE=01004"),
+			wantCode:  445,
+			wantState: "01004",
+		},
+		{
+			name:      "cli diagnostics",
+			err:       errors.New("SQLDriverConnect: {08001} [IBM][CLI Driver] CLI0124E  Invalid argument value."),
+			wantState: "08001",
+		},
+		{
+			name:    "not a db2 error",
+			err:     errors.New("payload is empty"),
+			wantNil: true,
+		},
+		{
+			name:    "nil",
+			wantNil: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := Parse(tt.err)
+			if tt.wantNil {
+				if got != nil {
+					t.Fatalf("Parse() = %v, want nil", got)
+				}
+
+				return
+			}
+
+			if got == nil {
+				t.Fatal("Parse() = nil")
+			}
+
+			if got.SQLCode != tt.wantCode || got.SQLState != tt.wantState {
+				t.Errorf("Parse() = %d, %q, want %d, %q", got.SQLCode, got.SQLState, tt.wantCode, tt.wantState)
+			}
+
+			if !errors.Is(got, tt.err) {
+				t.Errorf("Parse() must wrap %v", tt.err)
+			}
+		})
+	}
+}
+
+func TestWithTable_WithRecordIndex(t *testing.T) {
+	t.Parallel()
+
+	err := fmt.Errorf("route create: %w", WithRecordIndex(
+		fmt.Errorf("exec upsert: %w", WithTable(errors.New(deadlockMessage), "CLIENTS")), 3))
+
+	// the first table and index are kept.
+	err = WithRecordIndex(WithTable(err, "USERS"), 5)
+
+	db2Err := Parse(err)
+	if db2Err == nil {
+		t.Fatal("Parse() = nil")
+	}
+
+	if db2Err.Table != "CLIENTS" || db2Err.RecordIndex != 3 {
+		t.Errorf("Parse() table = %q, record index = %d, want %q, %d", db2Err.Table, db2Err.RecordIndex, "CLIENTS", 3)
+	}
+
+	want := `route create: exec upsert: table "CLIENTS", SQLCODE -911, SQLSTATE 40001: ` + deadlockMessage
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+
+	plain := errors.New("payload is empty")
+	if got := WithRecordIndex(WithTable(plain, "CLIENTS"), 1); got != plain { //nolint:errorlint // must be the same
+		t.Errorf("WithTable() = %v, want %v", got, plain)
+	}
+}
+
+func TestPredicates(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		is   func(error) bool
+		want bool
+	}{
+		{name: "deadlock", err: errors.New(deadlockMessage), is: IsDeadlock, want: true},
+		{name: "deadlock is not a lock timeout", err: errors.New(deadlockMessage), is: IsLockTimeout, want: false},
+		{
+			name: "lock timeout",
+			err:  errors.New("SQL0913N  Unsuccessful execution caused by deadlock or timeout.  SQLSTATE=57033"),
+			is:   IsLockTimeout,
+			want: true,
+		},
+		{
+			name: "duplicate key",
+			err:  &db2.Error{Diag: []db2.DiagRecord{{State: "23505", NativeError: -803}}},
+			is:   IsDuplicateKey,
+			want: true,
+		},
+		{
+			name: "string truncation",
+			err:  errors.New("SQL0302N  The value of a host variable is too large.  SQLSTATE=22001"),
+			is:   IsTruncation,
+			want: true,
+		},
+		{
+			name: "numeric overflow",
+			err:  &db2.Error{Diag: []db2.DiagRecord{{State: "22003", NativeError: -406}}},
+			is:   IsTruncation,
+			want: true,
+		},
+		{
+			name: "communication error",
+			err:  errors.New("SQL30081N  A communication error has been detected.  SQLSTATE=08001"),
+			is:   IsConnectionLost,
+			want: true,
+		},
+		{
+			name: "connection exception class",
+			err:  &db2.Error{Diag: []db2.DiagRecord{{State: "08003", NativeError: -99999}}},
+			is:   IsConnectionLost,
+			want: true,
+		},
+		{name: "bad connection", err: fmt.Errorf("exec: %w", driver.ErrBadConn), is: IsConnectionLost, want: true},
+		{name: "duplicate key is not connection lost", err: errors.New("SQL0803N"), is: IsConnectionLost, want: false},
+		{name: "not a db2 error", err: errors.New("failed"), is: IsDeadlock, want: false},
+		{name: "deadlock is transient", err: errors.New(deadlockMessage), is: IsTransient, want: true},
+		{
+			name: "communication error is transient",
+			err:  &db2.Error{Diag: []db2.DiagRecord{{State: "08001", NativeError: -30081}}},
+			is:   IsTransient,
+			want: true,
+		},
+		{name: "duplicate key is not transient", err: errors.New("SQL0803N"), is: IsTransient, want: false},
+		{name: "duplicate key is a data error", err: errors.New("SQL0803N"), is: IsDataError, want: true},
+		{
+			name: "not null violation is a data error",
+			err:  errors.New("SQL0407N  Assignment of a NULL value to a NOT NULL column is not allowed.  SQLSTATE=23502"),
+			is:   IsDataError,
+			want: true,
+		},
+		{
+			name: "invalid datetime is a data error",
+			err:  &db2.Error{Diag: []db2.DiagRecord{{State: "22007", NativeError: -180}}},
+			is:   IsDataError,
+			want: true,
+		},
+		{
+			name: "undefined table is not a data error",
+			err:  errors.New("SQL0204N  \"DB2INST1.CLIENTS\" is an undefined name.  SQLSTATE=42704"),
+			is:   IsDataError,
+			want: false,
+		},
+		{
+			name: "full log is not a data error",
+			err:  errors.New("SQL0964C  The transaction log for the database is full.  SQLSTATE=57011"),
+			is:   IsDataError,
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.is(tt.err); got != tt.want {
+				t.Errorf("predicate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package destination
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+)
+
+// lockTimeoutStatement returns the statement that sets the CURRENT LOCK TIMEOUT special register.
+func lockTimeoutStatement(seconds int) string {
+	if seconds < 0 {
+		return "SET CURRENT LOCK TIMEOUT WAIT"
+	}
+
+	return fmt.Sprintf("SET CURRENT LOCK TIMEOUT %d", seconds)
+}
+
+// withSessionStatements returns a database that opens connections by the driver of the db and executes
+// the statements on every new connection, since special registers are set per session. The db is closed.
+func withSessionStatements(db *sql.DB, dsn string, statements ...string) (*sql.DB, error) {
+	drv := db.Driver()
+
+	if err := db.Close(); err != nil {
+		return nil, fmt.Errorf("close database: %w", err)
+	}
+
+	return sql.OpenDB(&sessionConnector{dsn: dsn, driver: drv, statements: statements}), nil
+}
+
+// sessionConnector is a connector that executes the statements on every new connection.
+type sessionConnector struct {
+	dsn        string
+	driver     driver.Driver
+	statements []string
+}
+
+// Connect opens a connection by the driver and executes the statements on it.
+func (c *sessionConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.driver.Open(c.dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open connection: %w", err)
+	}
+
+	for _, statement := range c.statements {
+		if err = execSession(ctx, conn, statement); err != nil {
+			conn.Close() //nolint:errcheck // the statement error is returned
+
+			return nil, fmt.Errorf("execute %q: %w", statement, err)
+		}
+	}
+
+	return conn, nil
+}
+
+// Driver returns the driver of the connector.
+func (c *sessionConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// execSession executes the statement without arguments on the connection.
+func execSession(ctx context.Context, conn driver.Conn, statement string) error {
+	if execer, ok := conn.(driver.ExecerContext); ok {
+		_, err := execer.ExecContext(ctx, statement, nil)
+		if !errors.Is(err, driver.ErrSkip) {
+			return err
+		}
+	}
+
+	stmt, err := conn.Prepare(statement)
+	if err != nil {
+		return fmt.Errorf("prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	if _, err = stmt.Exec(nil); err != nil { //nolint:staticcheck // the driver may not implement StmtExecContext
+		return fmt.Errorf("exec: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package destination
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// testDriver is a driver of connections that record the executed statements.
+type testDriver struct {
+	executed []string
+	err      error
+}
+
+func (d *testDriver) Open(string) (driver.Conn, error) {
+	return &testConn{driver: d}, nil
+}
+
+// testConn is a connection that executes statements by the ExecerContext only.
+type testConn struct {
+	driver *testDriver
+}
+
+func (c *testConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.driver.executed = append(c.driver.executed, query)
+
+	return driver.RowsAffected(0), c.driver.err
+}
+
+func (c *testConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *testConn) Close() error {
+	return nil
+}
+
+func (c *testConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestLockTimeoutStatement(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		seconds int
+		want    string
+	}{
+		{seconds: -1, want: "SET CURRENT LOCK TIMEOUT WAIT"},
+		{seconds: 0, want: "SET CURRENT LOCK TIMEOUT 0"},
+		{seconds: 30, want: "SET CURRENT LOCK TIMEOUT 30"},
+	}
+
+	for _, tt := range tests {
+		if got := lockTimeoutStatement(tt.seconds); got != tt.want {
+			t.Errorf("lockTimeoutStatement(%d) = %q, want %q", tt.seconds, got, tt.want)
+		}
+	}
+}
+
+func TestSessionConnector_Connect(t *testing.T) {
+	t.Parallel()
+
+	errStatement := errors.New("SQL0104N  An unexpected token was found.  SQLSTATE=42601")
+
+	tests := []struct {
+		name    string
+		err     error
+		wantErr error
+	}{
+		{
+			name: "statements executed",
+		},
+		{
+			name:    "statement failed",
+			err:     errStatement,
+			wantErr: errStatement,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			drv := &testDriver{err: tt.err}
+
+			db := sql.OpenDB(&sessionConnector{
+				dsn:        "HOSTNAME=localhost",
+				driver:     drv,
+				statements: []string{lockTimeoutStatement(10)},
+			})
+			defer db.Close()
+
+			conn, err := db.Conn(context.Background())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Conn() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if conn != nil {
+				conn.Close()
+			}
+
+			if want := []string{"SET CURRENT LOCK TIMEOUT 10"}; !reflect.DeepEqual(drv.executed, want) {
+				t.Errorf("executed statements = %v, want %v", drv.executed, want)
+			}
+		})
+	}
+}
@@ -17,11 +17,16 @@ package destination
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strconv"
+	"time"
 
 	sdk "github.com/conduitio/conduit-connector-sdk"
 
+	"github.com/conduitio-labs/conduit-connector-db2/coltypes"
 	"github.com/conduitio-labs/conduit-connector-db2/config"
+	"github.com/conduitio-labs/conduit-connector-db2/db2errors"
 	"github.com/conduitio-labs/conduit-connector-db2/destination/writer"
 
 	_ "github.com/ibmdb/go_ibm_db" //nolint:revive,nolintlint
@@ -32,7 +37,7 @@ type Destination struct {
 	sdk.UnimplementedDestination
 
 	writer Writer
-	config config.Config
+	config config.Destination
 }
 
 // New creates new instance of the Destination.
@@ -44,10 +49,82 @@ func New() sdk.Destination {
 func (d *Destination) Parameters() map[string]sdk.Parameter {
 	return map[string]sdk.Parameter{
 		config.KeyConnection: {
-			Description: "Connection string to DB2",
-			Required:    true,
+			Description: "Connection string to DB2, required unless the host is set. It can't be combined with " +
+				"the discrete connection and SSL parameters, the timeouts and the pool settings apply to it too",
+			Required: false,
+			Default:  "",
+		},
+		config.KeyHost: {
+			Description: "A host name of the DB2 server, required unless the connection string is set",
+			Required:    false,
+			Default:     "",
+		},
+		config.KeyPort: {
+			Description: "A port of the DB2 server",
+			Required:    false,
+			Default:     strconv.Itoa(config.DefaultPort),
+		},
+		config.KeyDatabase: {
+			Description: "A name of the database, required if the host is set",
+			Required:    false,
+			Default:     "",
+		},
+		config.KeyUser: {
+			Description: "A name of the user",
+			Required:    false,
+			Default:     "",
+		},
+		config.KeyPassword: {
+			Description: "A password of the user",
+			Required:    false,
 			Default:     "",
 		},
+		config.KeyCurrentSchema: {
+			Description: "A schema of unqualified table names",
+			Required:    false,
+			Default:     "",
+		},
+		config.KeyConnectionParams: {
+			Description: "Extra CLI keywords of the connection string separated by semicolons, e.g. PROGRAMNAME=conduit",
+			Required:    false,
+			Default:     "",
+		},
+		config.KeyLoginTimeout: {
+			Description: "A time to wait for a connection to be established, rounded up to seconds, e.g. 10s. " +
+				"It's added to either connection string as the CONNECTTIMEOUT keyword",
+			Required: false,
+			Default:  "",
+		},
+		config.KeySSLEnabled: {
+			Description: "Whether to encrypt the connection by SSL",
+			Required:    false,
+			Default:     "false",
+		},
+		config.KeySSLServerCertificate: {
+			Description: "A path to the PEM encoded certificate of the DB2 server, or of its certificate authority",
+			Required:    false,
+			Default:     "",
+		},
+		config.KeySSLClientKeystoreDB: {
+			Description: "A path to the client keystore database (.kdb)",
+			Required:    false,
+			Default:     "",
+		},
+		config.KeySSLClientKeystash: {
+			Description: "A path to the stash file of the client keystore (.sth)",
+			Required:    false,
+			Default:     "",
+		},
+		config.KeySSLClientLabel: {
+			Description: "A label of the client certificate in the client keystore",
+			Required:    false,
+			Default:     "",
+		},
+		config.KeySSLCertificateAuth: {
+			Description: "Whether to authenticate by the client certificate instead of the user and password",
+			Required:    false,
+			Default:     "false",
+		},
 		config.KeyTable: {
 			Description: "name of the table that the connector should write to.",
 			Required:    true,
@@ -55,16 +132,212 @@ func (d *Destination) Parameters() map[string]sdk.Parameter {
 		},
 		config.KeyPrimaryKey: {
 			Description: "A column name that used to detect if the target table" +
-				" already contains the record (destination). It must be unique",
-			Required: true,
+				" already contains the record (destination). It must be unique." +
+				" Required for the upsert and scd2 write modes",
+			Required: false,
 			Default:  "",
 		},
+		config.KeyWriteMode: {
+			Description: "Defines how records are written: upsert merges records by the key column," +
+				" append inserts them as new rows without any key matching," +
+				" scd2 keeps the history of rows as a slowly changing dimension (Type 2)",
+			Required: false,
+			Default:  string(config.WriteModeUpsert),
+		},
+		config.KeyVersionColumn: {
+			Description: "A column name with a version or a timestamp of the row. If set, updates and deletes" +
+				" are skipped when the stored row has a newer version",
+			Required: false,
+			Default:  "",
+		},
+		config.KeyPartialUpdate: {
+			Description: "If true, records that contain payloads both before and after the change" +
+				" update only the changed columns",
+			Required: false,
+			Default:  "false",
+		},
+		config.KeyKeepMissingFields: {
+			Description: "If true, fields missing in the payload after the change are left unchanged" +
+				" during a partial update, otherwise they are set to NULL",
+			Required: false,
+			Default:  "false",
+		},
+		config.KeySCD2ValidFrom: {
+			Description: "A column with the time since which a version of the row is valid, for the scd2 write mode",
+			Required:    false,
+			Default:     config.DefaultSCD2ValidFrom,
+		},
+		config.KeySCD2ValidTo: {
+			Description: "A column with the time until which a version of the row is valid, for the scd2 write mode",
+			Required:    false,
+			Default:     config.DefaultSCD2ValidTo,
+		},
+		config.KeySCD2Current: {
+			Description: "A column that flags the current version of the row with 1, for the scd2 write mode",
+			Required:    false,
+			Default:     config.DefaultSCD2Current,
+		},
+		config.KeyPrefixAuditColumns + "*": {
+			Description: "Maps an audit column to a record field written to it:" +
+				" operation, position, createdAt, readAt or metadata.<key>," +
+				" e.g. auditColumns.SYNC_OP=operation",
+			Required: false,
+			Default:  "",
+		},
+		config.KeyPrefixColumnMapping + "*": {
+			Description: "Maps a record field to a column, nested fields are separated by dots," +
+				" e.g. columnMapping.address.city=CITY",
+			Required: false,
+			Default:  "",
+		},
+		config.KeyIncludeFields: {
+			Description: "A comma-separated list of record fields to write, all fields are written by default",
+			Required:    false,
+			Default:     "",
+		},
+		config.KeyExcludeFields: {
+			Description: "A comma-separated list of record fields that are not written",
+			Required:    false,
+			Default:     "",
+		},
+		config.KeyFlatten: {
+			Description: "If true, nested objects are expanded into columns prefixed by their names," +
+				" objects written to the columns of string types are stored as JSON strings",
+			Required: false,
+			Default:  "false",
+		},
+		config.KeyFlattenSeparator: {
+			Description: "A separator of the flattened nested objects and their fields",
+			Required:    false,
+			Default:     config.DefaultFlattenSeparator,
+		},
+		config.KeyUnknownColumns: {
+			Description: "What to do with payload fields that don't exist in the table: fail, ignore or evolve",
+			Required:    false,
+			Default:     string(config.UnknownColumnsFail),
+		},
+		config.KeyTimeZone: {
+			Description: "A time zone used to interpret and render dates and times",
+			Required:    false,
+			Default:     config.DefaultTimeZone,
+		},
+		config.KeyTimeLayouts: {
+			Description: "Go time layouts separated by '|', tried before the default ones when parsing times",
+			Required:    false,
+			Default:     "",
+		},
+		config.KeyEpochUnit: {
+			Description: "A unit of numeric times since the Unix epoch: s, ms, us or ns",
+			Required:    false,
+			Default:     string(config.DefaultEpochUnit),
+		},
+		config.KeyOverflowPolicy: {
+			Description: "What to do with values that don't fit their columns: fail, truncate or null",
+			Required:    false,
+			Default:     string(coltypes.OverflowFail),
+		},
+		config.KeyPrefixOverflowPolicy + "*": {
+			Description: "An overflow policy of the column, overriding the default one",
+			Required:    false,
+			Default:     "",
+		},
+		config.KeyBinaryEncoding: {
+			Description: "How strings written to binary columns are decoded: raw, base64 or hex",
+			Required:    false,
+			Default:     string(coltypes.BinaryRaw),
+		},
+		config.KeyXMLRootElement: {
+			Description: "A name of the root element of XML documents serialized from objects and arrays",
+			Required:    false,
+			Default:     config.DefaultXMLRootElement,
+		},
+		config.KeyLOBMaxInlineSize: {
+			Description: "A maximum size in bytes of LOB values written with their rows, 0 means no limit",
+			Required:    false,
+			Default:     "0",
+		},
+		config.KeyLOBPolicy: {
+			Description: "What to do with larger LOB values: chunked, truncate or skip",
+			Required:    false,
+			Default:     string(config.LOBChunked),
+		},
+		config.KeyLOBTruncatedColumn: {
+			Description: "A column that flags rows with truncated LOB values with 1",
+			Required:    false,
+			Default:     "",
+		},
+		config.KeyRetryMaxAttempts: {
+			Description: "A maximum number of attempts of statements and transactions failed with transient errors",
+			Required:    false,
+			Default:     strconv.Itoa(config.DefaultRetryMaxAttempts),
+		},
+		config.KeyRetryInitialBackoff: {
+			Description: "A delay before the first retry, it's doubled for every next retry",
+			Required:    false,
+			Default:     config.DefaultRetryInitialBackoff.String(),
+		},
+		config.KeyRetryMaxBackoff: {
+			Description: "A maximum delay between retries",
+			Required:    false,
+			Default:     config.DefaultRetryMaxBackoff.String(),
+		},
+		config.KeyRetryMaxElapsedTime: {
+			Description: "A total time budget of the attempts, 0 means no limit",
+			Required:    false,
+			Default:     config.DefaultRetryMaxElapsedTime.String(),
+		},
+		config.KeyReconnectMaxAttempts: {
+			Description: "A maximum number of attempts to reopen a lost connection, 0 disables reconnects",
+			Required:    false,
+			Default:     strconv.Itoa(config.DefaultReconnectMaxAttempts),
+		},
+		config.KeyDeadLetterTable: {
+			Description: "A table records that fail with permanent errors are written to, instead of failing the write",
+			Required:    false,
+			Default:     "",
+		},
+		config.KeyPoolMaxOpenConns: {
+			Description: "A maximum number of open connections, 0 means no limit",
+			Required:    false,
+			Default:     "0",
+		},
+		config.KeyPoolMaxIdleConns: {
+			Description: "A maximum number of idle connections",
+			Required:    false,
+			Default:     strconv.Itoa(config.DefaultPoolMaxIdleConns),
+		},
+		config.KeyPoolConnMaxLifetime: {
+			Description: "A maximum time a connection is reused for, 0 means no limit",
+			Required:    false,
+			Default:     "0",
+		},
+		config.KeyPoolConnMaxIdleTime: {
+			Description: "A maximum time a connection stays idle, 0 means no limit",
+			Required:    false,
+			Default:     "0",
+		},
+		config.KeyQueryTimeout: {
+			Description: "A maximum execution time of every statement that writes records, 0 means no limit",
+			Required:    false,
+			Default:     "0",
+		},
+		config.KeyLockTimeout: {
+			Description: "A number of seconds statements wait for locks, -1 means no limit, " +
+				"the server's default is used if empty",
+			Required: false,
+			Default:  "",
+		},
+		config.KeyAutoCreateTable: {
+			Description: "Whether to create tables that don't exist from the first records written to them",
+			Required:    false,
+			Default:     "false",
+		},
 	}
 }
 
 // Configure parses and initializes the config.
 func (d *Destination) Configure(ctx context.Context, cfg map[string]string) error {
-	configuration, err := config.Parse(cfg)
+	configuration, err := config.ParseDestination(cfg)
 	if err != nil {
 		return fmt.Errorf("parse config: %w", err)
 	}
@@ -76,19 +349,63 @@ func (d *Destination) Configure(ctx context.Context, cfg map[string]string) erro
 
 // Open makes sure everything is prepared to receive records.
 func (d *Destination) Open(ctx context.Context) error {
-	db, err := sql.Open("go_ibm_db", d.config.Connection)
+	db, err := d.connect(ctx)
 	if err != nil {
-		return fmt.Errorf("connect to db2: %w", err)
+		return err
 	}
 
-	if err = db.PingContext(ctx); err != nil {
-		return fmt.Errorf("ping db2: %w", err)
+	// the time zone has been validated by the config parsing.
+	location, err := time.LoadLocation(d.config.TimeZone)
+	if err != nil {
+		return fmt.Errorf("load time zone: %w", err)
 	}
 
 	d.writer, err = writer.NewWriter(ctx, writer.Params{
-		DB:        db,
-		Table:     d.config.Table,
-		KeyColumn: d.config.Key,
+		DB:                db,
+		Table:             d.config.Table,
+		KeyColumn:         d.config.Key,
+		VersionColumn:     d.config.VersionColumn,
+		PartialUpdate:     d.config.PartialUpdate,
+		KeepMissingFields: d.config.KeepMissingFields,
+		SCD2: writer.SCD2Columns{
+			ValidFrom: d.config.SCD2ValidFrom,
+			ValidTo:   d.config.SCD2ValidTo,
+			Current:   d.config.SCD2Current,
+		},
+		AuditColumns: d.config.AuditColumns,
+		FieldMapping: writer.FieldMapping{
+			Columns: d.config.ColumnMapping,
+			Include: d.config.IncludeFields,
+			Exclude: d.config.ExcludeFields,
+		},
+		Flatten:          d.config.Flatten,
+		FlattenSeparator: d.config.FlattenSeparator,
+		UnknownColumns:   d.config.UnknownColumns,
+		AutoCreateTable:  d.config.AutoCreateTable,
+		ConvertOptions: coltypes.Options{
+			Location:       location,
+			TimeLayouts:    d.config.TimeLayouts,
+			EpochUnit:      d.config.EpochUnit.Duration(),
+			Overflow:       d.config.OverflowPolicy,
+			ColumnOverflow: d.config.ColumnOverflowPolicies,
+			BinaryEncoding: d.config.BinaryEncoding,
+			XMLRootElement: d.config.XMLRootElement,
+		},
+		LOB: writer.LOBOptions{
+			MaxInlineSize:   d.config.LOBMaxInlineSize,
+			Policy:          d.config.LOBPolicy,
+			TruncatedColumn: d.config.LOBTruncatedColumn,
+		},
+		Retry: writer.RetryOptions{
+			MaxAttempts:    d.config.RetryMaxAttempts,
+			InitialBackoff: d.config.RetryInitialBackoff,
+			MaxBackoff:     d.config.RetryMaxBackoff,
+			MaxElapsedTime: d.config.RetryMaxElapsedTime,
+		},
+		Connect:              d.connect,
+		MaxReconnectAttempts: d.config.ReconnectMaxAttempts,
+		DeadLetterTable:      d.config.DeadLetterTable,
+		QueryTimeout:         d.config.QueryTimeout,
 	})
 
 	if err != nil {
@@ -98,8 +415,53 @@ func (d *Destination) Open(ctx context.Context) error {
 	return nil
 }
 
+// connect opens a pool of connections to the database configured by the pool settings, and pings it.
+// The password is masked in the returned errors.
+func (d *Destination) connect(ctx context.Context) (*sql.DB, error) {
+	db, err := sql.Open("go_ibm_db", d.config.Connection)
+	if err != nil {
+		return nil, fmt.Errorf("connect to db2: %w", config.MaskPassword(err))
+	}
+
+	if d.config.LockTimeout != nil {
+		db, err = withSessionStatements(db, d.config.Connection, lockTimeoutStatement(*d.config.LockTimeout))
+		if err != nil {
+			return nil, fmt.Errorf("connect to db2: %w", config.MaskPassword(err))
+		}
+	}
+
+	db.SetMaxOpenConns(d.config.PoolMaxOpenConns)
+	db.SetMaxIdleConns(d.config.PoolMaxIdleConns)
+	db.SetConnMaxLifetime(d.config.PoolConnMaxLifetime)
+	db.SetConnMaxIdleTime(d.config.PoolConnMaxIdleTime)
+
+	if err = db.PingContext(ctx); err != nil {
+		db.Close() //nolint:errcheck // the ping error is returned
+
+		return nil, fmt.Errorf("ping db2: %w", config.MaskPassword(err))
+	}
+
+	return db, nil
+}
+
 // Write writes a record into a Destination.
 func (d *Destination) Write(ctx context.Context, records []sdk.Record) (int, error) {
+	switch d.config.WriteMode {
+	case config.WriteModeAppend:
+		return d.writeAppend(ctx, records)
+	case config.WriteModeSCD2:
+		// records are written within a single transaction, so either all of them are written or none.
+		if err := d.writer.WriteSCD2(ctx, records); err != nil {
+			if !d.canDeadLetter(err) {
+				return 0, fmt.Errorf("write scd2: %w", err)
+			}
+
+			return d.writeSCD2Separately(ctx, records)
+		}
+
+		return len(records), nil
+	}
+
 	for i, record := range records {
 		err := sdk.Util.Destination.Route(ctx, record,
 			d.writer.Upsert,
@@ -108,13 +470,139 @@ func (d *Destination) Write(ctx context.Context, records []sdk.Record) (int, err
 			d.writer.Upsert,
 		)
 		if err != nil {
-			return i, fmt.Errorf("route %s: %w", record.Operation.String(), err)
+			if err = d.deadLetter(ctx, record, err); err != nil {
+				return i, fmt.Errorf("route %s: %w", record.Operation.String(), db2errors.WithRecordIndex(err, i))
+			}
 		}
 	}
 
 	return len(records), nil
 }
 
+// writeSCD2Separately writes every record within its own transaction, after the transaction of all records
+// has been rolled back, records that fail with permanent errors are written to the dead-letter table.
+func (d *Destination) writeSCD2Separately(ctx context.Context, records []sdk.Record) (int, error) {
+	for i, record := range records {
+		if err := d.writer.WriteSCD2(ctx, records[i:i+1]); err != nil {
+			if err = d.deadLetter(ctx, record, err); err != nil {
+				return i, fmt.Errorf("write scd2: %w", offsetRecordIndex(err, i))
+			}
+		}
+	}
+
+	return len(records), nil
+}
+
+// writeAppend inserts records as new rows. Consecutive non-delete records are inserted in batches,
+// delete records are applied in between, so the order of operations is preserved.
+func (d *Destination) writeAppend(ctx context.Context, records []sdk.Record) (int, error) {
+	start := 0
+
+	for i, record := range records {
+		if record.Operation != sdk.OperationDelete {
+			continue
+		}
+
+		if start < i {
+			if n, err := d.insert(ctx, records[start:i]); err != nil {
+				return start + n, fmt.Errorf("insert: %w", offsetRecordIndex(err, start))
+			}
+		}
+
+		if err := d.writer.Delete(ctx, record); err != nil {
+			if err = d.deadLetter(ctx, record, err); err != nil {
+				return i, fmt.Errorf("route %s: %w", record.Operation.String(), db2errors.WithRecordIndex(err, i))
+			}
+		}
+
+		start = i + 1
+	}
+
+	if start < len(records) {
+		if n, err := d.insert(ctx, records[start:]); err != nil {
+			return start + n, fmt.Errorf("insert: %w", offsetRecordIndex(err, start))
+		}
+	}
+
+	return len(records), nil
+}
+
+// insert inserts the records in batches and returns the number of processed records. If a batch fails
+// with a permanent error and the dead-letter table is configured, the records of the failed batch
+// are inserted one by one, the failing record is written to the dead-letter table, and the batches resume after it.
+func (d *Destination) insert(ctx context.Context, records []sdk.Record) (int, error) {
+	for start := 0; start < len(records); {
+		n, err := d.writer.Insert(ctx, records[start:])
+		if err == nil {
+			break
+		}
+
+		if !d.canDeadLetter(err) {
+			return start + n, offsetRecordIndex(err, start)
+		}
+
+		for start += n; start < len(records); start++ {
+			if _, err = d.writer.Insert(ctx, records[start:start+1]); err == nil {
+				continue
+			}
+
+			if err = d.deadLetter(ctx, records[start], err); err != nil {
+				return start, offsetRecordIndex(err, start)
+			}
+
+			start++
+
+			break
+		}
+	}
+
+	return len(records), nil
+}
+
+// deadLetter writes the record, that has failed with the err, to the dead-letter table, if the err is permanent
+// and the table is configured. It returns the err if the record hasn't been written to the dead-letter table.
+func (d *Destination) deadLetter(ctx context.Context, record sdk.Record, err error) error {
+	if !d.canDeadLetter(err) {
+		return err
+	}
+
+	if deadLetterErr := d.writer.WriteDeadLetter(ctx, record, err); deadLetterErr != nil {
+		return fmt.Errorf("%w (write dead letter: %v)", err, deadLetterErr)
+	}
+
+	return nil
+}
+
+// canDeadLetter reports whether the dead-letter table is configured and the err is caused by the record itself,
+// so writing it again won't help, but the other records may be written. Other errors, e.g. a missing table,
+// missing privileges or a full log, would send every record to the dead-letter table, so they fail the write.
+func (d *Destination) canDeadLetter(err error) bool {
+	return d.config.DeadLetterTable != "" && isRecordError(err)
+}
+
+// isRecordError reports whether the err is positively caused by the record: a conversion error, a data exception
+// or a constraint violation, fields that don't fit the table, or a missing payload or key.
+func isRecordError(err error) bool {
+	var (
+		unknownColumnsErr *writer.UnknownColumnsError
+		identifierErr     *writer.InvalidIdentifierError
+	)
+
+	switch {
+	case errors.Is(err, coltypes.ErrInvalidValue), errors.Is(err, coltypes.ErrValueOutOfRange),
+		errors.Is(err, coltypes.ErrMalformedXML), errors.Is(err, coltypes.ErrConvertDecFloat),
+		errors.Is(err, coltypes.ErrCannotConvertValueToBytes):
+		return true
+	case errors.As(err, &unknownColumnsErr), errors.As(err, &identifierErr):
+		return true
+	case errors.Is(err, writer.ErrEmptyPayload), errors.Is(err, writer.ErrEmptyKey),
+		errors.Is(err, writer.ErrEmptyVersion):
+		return true
+	default:
+		return db2errors.IsDataError(err)
+	}
+}
+
 // Teardown gracefully closes connections.
 func (d *Destination) Teardown(ctx context.Context) error {
 	if d.writer != nil {
@@ -123,3 +611,13 @@ func (d *Destination) Teardown(ctx context.Context) error {
 
 	return nil
 }
+
+// offsetRecordIndex shifts the record index of the DB2 error the err contains by the offset,
+// since the writer indexes the records of the slice it's provided with.
+func offsetRecordIndex(err error, offset int) error {
+	if db2Err := db2errors.Parse(err); db2Err != nil && db2Err.RecordIndex != db2errors.NoRecordIndex {
+		db2Err.RecordIndex += offset
+	}
+
+	return err
+}
@@ -17,6 +17,7 @@ package destination
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 
 	sdk "github.com/conduitio/conduit-connector-sdk"
@@ -24,6 +25,7 @@ import (
 
 	"github.com/matryer/is"
 
+	"github.com/conduitio-labs/conduit-connector-db2/coltypes"
 	"github.com/conduitio-labs/conduit-connector-db2/config"
 	"github.com/conduitio-labs/conduit-connector-db2/destination/mock"
 	"github.com/conduitio-labs/conduit-connector-db2/destination/writer"
@@ -72,6 +74,17 @@ func TestDestination_Configure(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "success, append mode without primary key",
+			args: args{
+				cfg: map[string]string{
+					config.KeyConnection: "HOSTNAME=localhost;DATABASE=testdb;PORT=50000;UID=DB2INST1;PWD=pwd",
+					config.KeyTable:      "CLIENTS",
+					config.KeyWriteMode:  "append",
+				},
+			},
+			wantErr: false,
+		},
 		{
 			name: "fail, missed primary key",
 			args: args{
@@ -162,6 +175,353 @@ func TestDestination_Write(t *testing.T) {
 	})
 }
 
+func TestDestination_Write_Append(t *testing.T) {
+	t.Parallel()
+
+	t.Run("success, deletes split inserts", func(t *testing.T) {
+		t.Parallel()
+
+		is := is.New(t)
+
+		ctrl := gomock.NewController(t)
+		ctx := context.Background()
+
+		records := []sdk.Record{
+			{Operation: sdk.OperationCreate, Payload: sdk.Change{After: sdk.StructuredData{"name": "a"}}},
+			{Operation: sdk.OperationUpdate, Payload: sdk.Change{After: sdk.StructuredData{"name": "b"}}},
+			{Operation: sdk.OperationDelete, Key: sdk.StructuredData{"ID": 1}},
+			{Operation: sdk.OperationSnapshot, Payload: sdk.Change{After: sdk.StructuredData{"name": "c"}}},
+		}
+
+		w := mock.NewMockWriter(ctrl)
+		gomock.InOrder(
+			w.EXPECT().Insert(ctx, records[:2]).Return(2, nil),
+			w.EXPECT().Delete(ctx, records[2]).Return(nil),
+			w.EXPECT().Insert(ctx, records[3:]).Return(1, nil),
+		)
+
+		d := Destination{
+			writer: w,
+			config: config.Destination{WriteMode: config.WriteModeAppend},
+		}
+
+		c, err := d.Write(ctx, records)
+		is.NoErr(err)
+
+		is.Equal(c, 4)
+	})
+
+	t.Run("fail, insert error", func(t *testing.T) {
+		t.Parallel()
+
+		is := is.New(t)
+
+		ctrl := gomock.NewController(t)
+		ctx := context.Background()
+
+		records := []sdk.Record{
+			{Operation: sdk.OperationDelete, Key: sdk.StructuredData{"ID": 1}},
+			{Operation: sdk.OperationCreate, Payload: sdk.Change{After: sdk.StructuredData{"name": "a"}}},
+		}
+
+		w := mock.NewMockWriter(ctrl)
+		w.EXPECT().Delete(ctx, records[0]).Return(nil)
+		w.EXPECT().Insert(ctx, records[1:]).Return(0, errors.New("some error"))
+
+		d := Destination{
+			writer: w,
+			config: config.Destination{WriteMode: config.WriteModeAppend},
+		}
+
+		c, err := d.Write(ctx, records)
+		is.Equal(err != nil, true)
+
+		is.Equal(c, 1)
+	})
+
+	t.Run("fail, insert error after flushed rows", func(t *testing.T) {
+		t.Parallel()
+
+		is := is.New(t)
+
+		ctrl := gomock.NewController(t)
+		ctx := context.Background()
+
+		records := []sdk.Record{
+			{Operation: sdk.OperationDelete, Key: sdk.StructuredData{"ID": 1}},
+			{Operation: sdk.OperationCreate, Payload: sdk.Change{After: sdk.StructuredData{"name": "a"}}},
+			{Operation: sdk.OperationCreate, Payload: sdk.Change{After: sdk.StructuredData{"name": "b"}}},
+			{Operation: sdk.OperationCreate, Payload: sdk.Change{After: sdk.StructuredData{"id": "c"}}},
+		}
+
+		w := mock.NewMockWriter(ctrl)
+		w.EXPECT().Delete(ctx, records[0]).Return(nil)
+		// the rows of the first two records are flushed before the column set changes.
+		w.EXPECT().Insert(ctx, records[1:]).Return(2, errors.New("some error"))
+
+		d := Destination{
+			writer: w,
+			config: config.Destination{WriteMode: config.WriteModeAppend},
+		}
+
+		c, err := d.Write(ctx, records)
+		is.Equal(err != nil, true)
+
+		// the flushed rows are counted as written, so they aren't redelivered and duplicated.
+		is.Equal(c, 3)
+	})
+}
+
+func TestDestination_Write_SCD2(t *testing.T) {
+	t.Parallel()
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		is := is.New(t)
+
+		ctrl := gomock.NewController(t)
+		ctx := context.Background()
+
+		records := []sdk.Record{
+			{Operation: sdk.OperationUpdate, Payload: sdk.Change{After: sdk.StructuredData{"ID": 1}}},
+			{Operation: sdk.OperationDelete, Key: sdk.StructuredData{"ID": 1}},
+		}
+
+		w := mock.NewMockWriter(ctrl)
+		w.EXPECT().WriteSCD2(ctx, records).Return(nil)
+
+		d := Destination{
+			writer: w,
+			config: config.Destination{WriteMode: config.WriteModeSCD2},
+		}
+
+		c, err := d.Write(ctx, records)
+		is.NoErr(err)
+
+		is.Equal(c, 2)
+	})
+
+	t.Run("fail, nothing is written", func(t *testing.T) {
+		t.Parallel()
+
+		is := is.New(t)
+
+		ctrl := gomock.NewController(t)
+		ctx := context.Background()
+
+		records := []sdk.Record{
+			{Operation: sdk.OperationUpdate, Payload: sdk.Change{After: sdk.StructuredData{"ID": 1}}},
+		}
+
+		w := mock.NewMockWriter(ctrl)
+		w.EXPECT().WriteSCD2(ctx, records).Return(errors.New("some error"))
+
+		d := Destination{
+			writer: w,
+			config: config.Destination{WriteMode: config.WriteModeSCD2},
+		}
+
+		c, err := d.Write(ctx, records)
+		is.Equal(err != nil, true)
+
+		is.Equal(c, 0)
+	})
+}
+
+func TestDestination_Write_DeadLetter(t *testing.T) {
+	t.Parallel()
+
+	errConvert := fmt.Errorf("convert structure data: %w", coltypes.ErrInvalidValue)
+	errDeadlock := errors.New("SQL0911N  The current transaction has been rolled back.  SQLSTATE=40001")
+
+	t.Run("success, upsert", func(t *testing.T) {
+		t.Parallel()
+
+		is := is.New(t)
+
+		ctrl := gomock.NewController(t)
+		ctx := context.Background()
+
+		records := []sdk.Record{
+			{Operation: sdk.OperationCreate, Payload: sdk.Change{After: sdk.StructuredData{"ID": 1}}},
+			{Operation: sdk.OperationCreate, Payload: sdk.Change{After: sdk.StructuredData{"ID": "a"}}},
+			{Operation: sdk.OperationCreate, Payload: sdk.Change{After: sdk.StructuredData{"ID": 3}}},
+		}
+
+		w := mock.NewMockWriter(ctrl)
+		gomock.InOrder(
+			w.EXPECT().Upsert(ctx, records[0]).Return(nil),
+			w.EXPECT().Upsert(ctx, records[1]).Return(errConvert),
+			w.EXPECT().WriteDeadLetter(ctx, records[1], errConvert).Return(nil),
+			w.EXPECT().Upsert(ctx, records[2]).Return(nil),
+		)
+
+		d := Destination{
+			writer: w,
+			config: config.Destination{DeadLetterTable: "CLIENTS_DLQ"},
+		}
+
+		c, err := d.Write(ctx, records)
+		is.NoErr(err)
+
+		is.Equal(c, 3)
+	})
+
+	t.Run("success, append", func(t *testing.T) {
+		t.Parallel()
+
+		is := is.New(t)
+
+		ctrl := gomock.NewController(t)
+		ctx := context.Background()
+
+		records := []sdk.Record{
+			{Operation: sdk.OperationCreate, Payload: sdk.Change{After: sdk.StructuredData{"name": "a"}}},
+			{Operation: sdk.OperationCreate, Payload: sdk.Change{After: sdk.StructuredData{"name": "b"}}},
+			{Operation: sdk.OperationCreate, Payload: sdk.Change{After: sdk.StructuredData{"name": 3}}},
+			{Operation: sdk.OperationCreate, Payload: sdk.Change{After: sdk.StructuredData{"name": "d"}}},
+		}
+
+		w := mock.NewMockWriter(ctrl)
+		gomock.InOrder(
+			w.EXPECT().Insert(ctx, records).Return(1, errConvert),
+			w.EXPECT().Insert(ctx, records[1:2]).Return(1, nil),
+			w.EXPECT().Insert(ctx, records[2:3]).Return(0, errConvert),
+			w.EXPECT().WriteDeadLetter(ctx, records[2], errConvert).Return(nil),
+			w.EXPECT().Insert(ctx, records[3:]).Return(1, nil),
+		)
+
+		d := Destination{
+			writer: w,
+			config: config.Destination{WriteMode: config.WriteModeAppend, DeadLetterTable: "CLIENTS_DLQ"},
+		}
+
+		c, err := d.Write(ctx, records)
+		is.NoErr(err)
+
+		is.Equal(c, 4)
+	})
+
+	t.Run("success, scd2", func(t *testing.T) {
+		t.Parallel()
+
+		is := is.New(t)
+
+		ctrl := gomock.NewController(t)
+		ctx := context.Background()
+
+		records := []sdk.Record{
+			{Operation: sdk.OperationUpdate, Payload: sdk.Change{After: sdk.StructuredData{"ID": "a"}}},
+			{Operation: sdk.OperationUpdate, Payload: sdk.Change{After: sdk.StructuredData{"ID": 2}}},
+		}
+
+		w := mock.NewMockWriter(ctrl)
+		gomock.InOrder(
+			w.EXPECT().WriteSCD2(ctx, records).Return(errConvert),
+			w.EXPECT().WriteSCD2(ctx, records[:1]).Return(errConvert),
+			w.EXPECT().WriteDeadLetter(ctx, records[0], errConvert).Return(nil),
+			w.EXPECT().WriteSCD2(ctx, records[1:]).Return(nil),
+		)
+
+		d := Destination{
+			writer: w,
+			config: config.Destination{WriteMode: config.WriteModeSCD2, DeadLetterTable: "CLIENTS_DLQ"},
+		}
+
+		c, err := d.Write(ctx, records)
+		is.NoErr(err)
+
+		is.Equal(c, 2)
+	})
+
+	t.Run("fail, systemic errors", func(t *testing.T) {
+		t.Parallel()
+
+		for _, errSystemic := range []error{
+			errors.New("SQL0204N  \"DB2INST1.CLIENTS\" is an undefined name.  SQLSTATE=42704"),
+			errors.New("SQL0551N  The user does not have the required authorization.  SQLSTATE=42501"),
+			errors.New("SQL0964C  The transaction log for the database is full.  SQLSTATE=57011"),
+			errors.New("some error"),
+		} {
+			is := is.New(t)
+
+			ctrl := gomock.NewController(t)
+			ctx := context.Background()
+
+			records := []sdk.Record{
+				{Operation: sdk.OperationCreate, Payload: sdk.Change{After: sdk.StructuredData{"ID": 1}}},
+			}
+
+			w := mock.NewMockWriter(ctrl)
+			w.EXPECT().Upsert(ctx, records[0]).Return(errSystemic)
+
+			d := Destination{
+				writer: w,
+				config: config.Destination{DeadLetterTable: "CLIENTS_DLQ"},
+			}
+
+			c, err := d.Write(ctx, records)
+			is.True(errors.Is(err, errSystemic))
+
+			is.Equal(c, 0)
+		}
+	})
+
+	t.Run("fail, transient error", func(t *testing.T) {
+		t.Parallel()
+
+		is := is.New(t)
+
+		ctrl := gomock.NewController(t)
+		ctx := context.Background()
+
+		records := []sdk.Record{
+			{Operation: sdk.OperationCreate, Payload: sdk.Change{After: sdk.StructuredData{"ID": 1}}},
+		}
+
+		w := mock.NewMockWriter(ctrl)
+		w.EXPECT().Upsert(ctx, records[0]).Return(errDeadlock)
+
+		d := Destination{
+			writer: w,
+			config: config.Destination{DeadLetterTable: "CLIENTS_DLQ"},
+		}
+
+		c, err := d.Write(ctx, records)
+		is.True(errors.Is(err, errDeadlock))
+
+		is.Equal(c, 0)
+	})
+
+	t.Run("fail, dead letter error", func(t *testing.T) {
+		t.Parallel()
+
+		is := is.New(t)
+
+		ctrl := gomock.NewController(t)
+		ctx := context.Background()
+
+		records := []sdk.Record{
+			{Operation: sdk.OperationCreate, Payload: sdk.Change{After: sdk.StructuredData{"ID": "a"}}},
+		}
+
+		w := mock.NewMockWriter(ctrl)
+		w.EXPECT().Upsert(ctx, records[0]).Return(errConvert)
+		w.EXPECT().WriteDeadLetter(ctx, records[0], errConvert).Return(errDeadlock)
+
+		d := Destination{
+			writer: w,
+			config: config.Destination{DeadLetterTable: "CLIENTS_DLQ"},
+		}
+
+		c, err := d.Write(ctx, records)
+		is.True(errors.Is(err, errConvert))
+
+		is.Equal(c, 0)
+	})
+}
+
 func TestDestination_Teardown(t *testing.T) {
 	t.Parallel()
 
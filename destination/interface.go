@@ -24,5 +24,8 @@ import (
 type Writer interface {
 	Delete(ctx context.Context, record sdk.Record) error
 	Upsert(ctx context.Context, record sdk.Record) error
+	Insert(ctx context.Context, records []sdk.Record) (int, error)
+	WriteSCD2(ctx context.Context, records []sdk.Record) error
+	WriteDeadLetter(ctx context.Context, record sdk.Record, cause error) error
 	Close(ctx context.Context) error
 }
@@ -63,6 +63,21 @@ func (mr *MockWriterMockRecorder) Delete(ctx, record interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockWriter)(nil).Delete), ctx, record)
 }
 
+// Insert mocks base method.
+func (m *MockWriter) Insert(ctx context.Context, records []sdk.Record) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Insert", ctx, records)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Insert indicates an expected call of Insert.
+func (mr *MockWriterMockRecorder) Insert(ctx, records interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockWriter)(nil).Insert), ctx, records)
+}
+
 // Upsert mocks base method.
 func (m *MockWriter) Upsert(ctx context.Context, record sdk.Record) error {
 	m.ctrl.T.Helper()
@@ -76,3 +91,31 @@ func (mr *MockWriterMockRecorder) Upsert(ctx, record interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upsert", reflect.TypeOf((*MockWriter)(nil).Upsert), ctx, record)
 }
+
+// WriteDeadLetter mocks base method.
+func (m *MockWriter) WriteDeadLetter(ctx context.Context, record sdk.Record, cause error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteDeadLetter", ctx, record, cause)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteDeadLetter indicates an expected call of WriteDeadLetter.
+func (mr *MockWriterMockRecorder) WriteDeadLetter(ctx, record, cause interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteDeadLetter", reflect.TypeOf((*MockWriter)(nil).WriteDeadLetter), ctx, record, cause)
+}
+
+// WriteSCD2 mocks base method.
+func (m *MockWriter) WriteSCD2(ctx context.Context, records []sdk.Record) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteSCD2", ctx, records)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteSCD2 indicates an expected call of WriteSCD2.
+func (mr *MockWriterMockRecorder) WriteSCD2(ctx, records interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteSCD2", reflect.TypeOf((*MockWriter)(nil).WriteSCD2), ctx, records)
+}
@@ -0,0 +1,132 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	sdk "github.com/conduitio/conduit-connector-sdk"
+
+	"github.com/conduitio-labs/conduit-connector-db2/coltypes"
+	"github.com/conduitio-labs/conduit-connector-db2/db2errors"
+)
+
+const (
+	// scd2ValidityType is a type of the SCD2 validity columns of created tables.
+	scd2ValidityType = "TIMESTAMP"
+	// scd2CurrentType is a type of the SCD2 current version flag column of created tables.
+	scd2CurrentType = "SMALLINT"
+	// keyStringType is a type of string key columns of created tables, DB2 keys can't be LOBs.
+	keyStringType = "VARCHAR(255)"
+)
+
+// createTable creates the table with the columns if auto-creation is enabled and the catalog has no columns
+// of the table, and returns the refreshed schema of the table. The columns map names to type definitions.
+// Primary key columns are created as NOT NULL, the primary key is omitted if there are no such columns.
+func (w *Writer) createTable(
+	ctx context.Context,
+	q execQuerier,
+	table string,
+	schema *tableSchema,
+	columns map[string]string,
+	primaryKey []string,
+) (*tableSchema, error) {
+	if !w.autoCreateTable || len(schema.columnTypes) > 0 || len(columns) == 0 {
+		return schema, nil
+	}
+
+	if err := checkTableName(table); err != nil {
+		return nil, err
+	}
+
+	for column := range columns {
+		if err := checkIdentifier(column); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := q.ExecContext(ctx, buildCreateTableQuery(table, columns, primaryKey)); err != nil {
+		return nil, fmt.Errorf("exec create table: %w", db2errors.WithTable(err, table))
+	}
+
+	sdk.Logger(ctx).Info().Str("table", table).Msg("created the table")
+
+	created, err := loadSchema(ctx, q, strings.ToUpper(table))
+	if err != nil {
+		return nil, fmt.Errorf("refresh table schema: %w", err)
+	}
+
+	w.schemas[strings.ToUpper(table)] = created
+
+	return created, nil
+}
+
+// buildCreateTableQuery generates an SQL CREATE TABLE statement. Columns are sorted by name.
+// The table and the columns must be checked to be valid identifiers.
+func buildCreateTableQuery(table string, columns map[string]string, primaryKey []string) string {
+	// key columns that have no values in the record are left out.
+	keyColumns := make(map[string]struct{}, len(primaryKey))
+	key := make([]string, 0, len(primaryKey))
+
+	for _, column := range primaryKey {
+		column = strings.ToUpper(column)
+		if _, ok := columns[column]; ok {
+			keyColumns[column] = struct{}{}
+			key = append(key, column)
+		}
+	}
+
+	names := make([]string, 0, len(columns))
+	for name := range columns {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	definitions := make([]string, 0, len(names)+1)
+
+	for _, name := range names {
+		definition := name + " " + columns[name]
+
+		if _, ok := keyColumns[name]; ok {
+			if strings.HasPrefix(definition, name+" CLOB") {
+				definition = name + " " + keyStringType
+			}
+
+			definition += " NOT NULL"
+		}
+
+		definitions = append(definitions, definition)
+	}
+
+	if len(key) > 0 {
+		definitions = append(definitions, "PRIMARY KEY ("+strings.Join(key, ", ")+")")
+	}
+
+	return "CREATE TABLE " + table + " (" + strings.Join(definitions, ", ") + ")"
+}
+
+// inferColumnTypes returns type definitions of columns for the data's fields by uppercased names.
+func inferColumnTypes(data sdk.StructuredData) map[string]string {
+	columns := make(map[string]string, len(data))
+	for field, value := range data {
+		columns[strings.ToUpper(field)] = coltypes.InferType(value)
+	}
+
+	return columns
+}
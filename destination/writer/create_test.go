@@ -0,0 +1,281 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	sdk "github.com/conduitio/conduit-connector-sdk"
+
+	"github.com/conduitio-labs/conduit-connector-db2/config"
+)
+
+// testCatalogSchema is a current schema of the connections to the testCatalog.
+const testCatalogSchema = "DB2INST1"
+
+// testCatalog is a connector of databases that keep the columns of the created tables in the catalog
+// and accept any other statements. Tables are stored by their names qualified by schemas.
+type testCatalog struct {
+	mu      sync.Mutex
+	tables  map[string][]string
+	created []string
+}
+
+func (c *testCatalog) Connect(context.Context) (driver.Conn, error) {
+	return &testCatalogConn{catalog: c}, nil
+}
+
+func (c *testCatalog) Driver() driver.Driver {
+	return nil
+}
+
+// testCatalogConn is a connection to the testCatalog.
+type testCatalogConn struct {
+	catalog *testCatalog
+}
+
+func (c *testCatalogConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	// the catalog queries take the schema and the table as the only arguments.
+	schema, ok := args[0].Value.(string)
+	if !ok {
+		schema = testCatalogSchema
+	}
+
+	c.catalog.mu.Lock()
+	defer c.catalog.mu.Unlock()
+
+	rows := &testCatalogRows{}
+	if strings.Contains(query, "generated = 'A'") {
+		return rows, nil
+	}
+
+	for _, column := range c.catalog.tables[schema+"."+args[1].Value.(string)] {
+		rows.values = append(rows.values, []driver.Value{column, "VARCHAR", "", int64(255), int64(0), int64(1208)})
+	}
+
+	return rows, nil
+}
+
+func (c *testCatalogConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	table, definitions, ok := strings.Cut(strings.TrimPrefix(query, "CREATE TABLE "), " (")
+	if !ok || !strings.HasPrefix(query, "CREATE TABLE ") {
+		return driver.RowsAffected(1), nil
+	}
+
+	// unquoted identifiers are folded to uppercase.
+	table = strings.ToUpper(table)
+	if !strings.Contains(table, ".") {
+		table = testCatalogSchema + "." + table
+	}
+
+	c.catalog.mu.Lock()
+	defer c.catalog.mu.Unlock()
+
+	if _, ok = c.catalog.tables[table]; ok {
+		return nil, errors.New("SQL0601N  The name of the object to be created is identical to the existing name. " +
+			"SQLSTATE=42710")
+	}
+
+	var columns []string
+	for _, definition := range strings.Split(definitions, ", ") {
+		if column := strings.Fields(definition)[0]; column != "PRIMARY" {
+			columns = append(columns, column)
+		}
+	}
+
+	c.catalog.tables[table] = columns
+	c.catalog.created = append(c.catalog.created, table)
+
+	return driver.RowsAffected(0), nil
+}
+
+func (c *testCatalogConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *testCatalogConn) Close() error {
+	return nil
+}
+
+func (c *testCatalogConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+// testCatalogRows are rows of the column types query.
+type testCatalogRows struct {
+	values [][]driver.Value
+}
+
+func (r *testCatalogRows) Columns() []string {
+	return []string{"column_name", "data_type", "user_type", "length", "scale", "codepage"}
+}
+
+func (r *testCatalogRows) Close() error {
+	return nil
+}
+
+func (r *testCatalogRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+
+	return nil
+}
+
+func TestBuildCreateTableQuery(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		data       sdk.StructuredData
+		primaryKey []string
+		want       string
+	}{
+		{
+			name:       "with primary key",
+			data:       sdk.StructuredData{"id": float64(1), "name": "Bob", "active": true},
+			primaryKey: []string{"id"},
+			want:       "CREATE TABLE CLIENTS (ACTIVE BOOLEAN, ID BIGINT NOT NULL, NAME VARCHAR(255), PRIMARY KEY (ID))",
+		},
+		{
+			name:       "without primary key",
+			data:       sdk.StructuredData{"id": float64(1), "tags": []any{"a"}},
+			primaryKey: nil,
+			want:       "CREATE TABLE CLIENTS (ID BIGINT, TAGS CLOB(1M))",
+		},
+		{
+			name:       "missing key column",
+			data:       sdk.StructuredData{"name": "Bob"},
+			primaryKey: []string{"ID"},
+			want:       "CREATE TABLE CLIENTS (NAME VARCHAR(255))",
+		},
+		{
+			name:       "object key column",
+			data:       sdk.StructuredData{"id": map[string]any{"a": 1}},
+			primaryKey: []string{"ID"},
+			want:       "CREATE TABLE CLIENTS (ID VARCHAR(255) NOT NULL, PRIMARY KEY (ID))",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := buildCreateTableQuery("CLIENTS", inferColumnTypes(tt.data), tt.primaryKey)
+			if got != tt.want {
+				t.Errorf("buildCreateTableQuery() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriter_createTable_invalidIdentifier(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		table   string
+		columns map[string]string
+	}{
+		{
+			name:    "invalid table",
+			table:   "CLIENTS;DROP TABLE USERS",
+			columns: map[string]string{"ID": "BIGINT"},
+		},
+		{
+			name:    "invalid column",
+			table:   "CLIENTS",
+			columns: map[string]string{"ID": "BIGINT", "FULL NAME": "VARCHAR(255)"},
+		},
+		{
+			name:    "reserved column",
+			table:   "CLIENTS",
+			columns: map[string]string{"SELECT": "VARCHAR(255)"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := &Writer{autoCreateTable: true}
+
+			// the statement is never executed, so no database is needed.
+			_, err := w.createTable(context.Background(), nil, tt.table, &tableSchema{}, tt.columns, nil)
+
+			var identifierErr *InvalidIdentifierError
+			if !errors.As(err, &identifierErr) {
+				t.Errorf("createTable() error = %v, want *InvalidIdentifierError", err)
+			}
+		})
+	}
+}
+
+func TestWriter_createTable_qualifiedName(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	catalog := &testCatalog{tables: make(map[string][]string)}
+
+	w, err := NewWriter(ctx, Params{
+		DB:              sql.OpenDB(catalog),
+		Table:           "sales.clients",
+		KeyColumn:       "id",
+		UnknownColumns:  config.UnknownColumnsFail,
+		AutoCreateTable: true,
+	})
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		err = w.Upsert(ctx, sdk.Record{
+			Key:     sdk.StructuredData{"id": "1"},
+			Payload: sdk.Change{After: sdk.StructuredData{"name": "Bob"}},
+		})
+		if err != nil {
+			t.Fatalf("Upsert() error = %v", err)
+		}
+	}
+
+	if len(catalog.created) != 1 || catalog.created[0] != "SALES.CLIENTS" {
+		t.Errorf("created tables = %v, want [SALES.CLIENTS]", catalog.created)
+	}
+
+	// the refreshed schema has the columns, so the unknown fields are checked.
+	err = w.Upsert(ctx, sdk.Record{
+		Key:     sdk.StructuredData{"id": "1"},
+		Payload: sdk.Change{After: sdk.StructuredData{"name": "Bob", "age": "42"}},
+	})
+
+	var unknownErr *UnknownColumnsError
+	if !errors.As(err, &unknownErr) {
+		t.Errorf("Upsert() error = %v, want *UnknownColumnsError", err)
+	}
+}
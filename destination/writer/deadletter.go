@@ -0,0 +1,111 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sdk "github.com/conduitio/conduit-connector-sdk"
+
+	"github.com/conduitio-labs/conduit-connector-db2/db2errors"
+)
+
+// dead-letter table columns.
+const (
+	deadLetterRecord    = "RECORD"
+	deadLetterError     = "ERROR"
+	deadLetterSQLCode   = "SQLCODE"
+	deadLetterSQLState  = "SQLSTATE"
+	deadLetterCreatedAt = "CREATED_AT"
+
+	// maxDeadLetterErrorSize is a maximum size in bytes of the error messages, longer ones are truncated.
+	maxDeadLetterErrorSize = 4000
+)
+
+// deadLetterColumns contains type definitions of the dead-letter table columns.
+var deadLetterColumns = map[string]string{
+	deadLetterRecord:    "CLOB(2G)",
+	deadLetterError:     fmt.Sprintf("VARCHAR(%d)", maxDeadLetterErrorSize),
+	deadLetterSQLCode:   "INTEGER",
+	deadLetterSQLState:  "CHAR(5)",
+	deadLetterCreatedAt: "TIMESTAMP",
+}
+
+// createDeadLetterTable creates the dead-letter table if the catalog has no columns of it.
+func (w *Writer) createDeadLetterTable(ctx context.Context) error {
+	schema, err := loadSchema(ctx, w.db, w.deadLetterTable)
+	if err != nil {
+		return fmt.Errorf("get table schema: %w", err)
+	}
+
+	if len(schema.columnTypes) > 0 {
+		return nil
+	}
+
+	if _, err = w.db.ExecContext(ctx, buildCreateTableQuery(w.deadLetterTable, deadLetterColumns, nil)); err != nil {
+		return fmt.Errorf("exec create table: %w", db2errors.WithTable(err, w.deadLetterTable))
+	}
+
+	sdk.Logger(ctx).Info().Str("table", w.deadLetterTable).Msg("created the dead-letter table")
+
+	return nil
+}
+
+// WriteDeadLetter writes the record, that can't be written because of the cause, to the dead-letter table
+// as JSON, along with the cause's message, SQLCODE and SQLSTATE, if they're known, and the current time.
+func (w *Writer) WriteDeadLetter(ctx context.Context, record sdk.Record, cause error) error {
+	if w.deadLetterTable == "" {
+		return ErrNoDeadLetterTable
+	}
+
+	var sqlCode, sqlState any
+	if db2Err := db2errors.Parse(cause); db2Err != nil {
+		if db2Err.SQLCode != 0 {
+			sqlCode = db2Err.SQLCode
+		}
+
+		if db2Err.SQLState != "" {
+			sqlState = db2Err.SQLState
+		}
+	}
+
+	message := cause.Error()
+	if len(message) > maxDeadLetterErrorSize {
+		message = splitLOB(message, maxDeadLetterErrorSize)[0].(string) //nolint:forcetypeassert // strings are split
+	}
+
+	columns := []string{deadLetterRecord, deadLetterError, deadLetterSQLCode, deadLetterSQLState, deadLetterCreatedAt}
+	values := []any{string(record.Bytes()), message, sqlCode, sqlState, time.Now().UTC()}
+
+	query, args := w.buildInsertQuery(w.deadLetterTable, columns, [][]any{values})
+
+	err := w.retry(ctx, "dead letter", func() error {
+		_, err := w.execContext(ctx, w.db, query, args...)
+
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("exec insert: %w", db2errors.WithTable(err, w.deadLetterTable))
+	}
+
+	sdk.Logger(ctx).Warn().
+		Err(cause).
+		Str("table", w.deadLetterTable).
+		Msg("the record can't be written, it's written to the dead-letter table")
+
+	return nil
+}
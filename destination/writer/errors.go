@@ -14,7 +14,11 @@
 
 package writer
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
 
 var (
 	// ErrEmptyPayload occurs when there's no payload to insert.
@@ -23,6 +27,39 @@ var (
 	ErrEmptyKey = errors.New("key value must be provided")
 	// ErrCompositeKeysNotSupported occurs when there are more than one key in a Key map.
 	ErrCompositeKeysNotSupported = errors.New("composite keys not yet supported")
+	// ErrEmptyVersion occurs when there is no value for the version column.
+	ErrEmptyVersion = errors.New("version value must be provided")
+	// ErrUnknownMappedColumns occurs when the field mapping refers to columns that don't exist in the table.
+	ErrUnknownMappedColumns = errors.New("mapped columns don't exist in the table")
+	// ErrLOBChunksNotSupported occurs when LOB chunks are appended to rows that can't be matched.
+	ErrLOBChunksNotSupported = errors.New("appending LOB chunks is not supported by the write mode")
+	// ErrNoDeadLetterTable occurs when a record is written to the dead-letter table, but it's not configured.
+	ErrNoDeadLetterTable = errors.New("dead-letter table is not configured")
 	// ErrColumnsValuesLenMismatch occurs when trying to insert a row with a different column and value lengths.
 	ErrColumnsValuesLenMismatch = errors.New("number of columns must be equal to number of values")
 )
+
+// UnknownColumnsError occurs when the payload contains fields that don't exist in the table.
+type UnknownColumnsError struct {
+	// Table is a name of the table.
+	Table string
+	// Columns contains the sorted names of the unknown fields.
+	Columns []string
+}
+
+// Error returns a message of the error.
+func (e *UnknownColumnsError) Error() string {
+	return fmt.Sprintf("table %q doesn't have columns for the fields: %s", e.Table, strings.Join(e.Columns, ", "))
+}
+
+// InvalidIdentifierError occurs when a table or a column created by the writer has a name that is not
+// a DB2 ordinary identifier.
+type InvalidIdentifierError struct {
+	// Name is the invalid name.
+	Name string
+}
+
+// Error returns a message of the error.
+func (e *InvalidIdentifierError) Error() string {
+	return fmt.Sprintf("%q is not a valid DB2 identifier", e.Name)
+}
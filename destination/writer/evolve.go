@@ -0,0 +1,142 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	sdk "github.com/conduitio/conduit-connector-sdk"
+
+	"github.com/conduitio-labs/conduit-connector-db2/coltypes"
+	"github.com/conduitio-labs/conduit-connector-db2/db2errors"
+)
+
+const (
+	// queryReorgPending is a query that counts the tables with the name that are in the REORG-pending state.
+	queryReorgPending = `
+			SELECT 
+				   count(*)
+			from sysibmadm.admintabinfo
+			where tabname = '%s' and reorg_pending = 'Y'
+`
+	// queryReorgTable is a query that reorganizes the table, it's required after some ALTER TABLE statements.
+	queryReorgTable = "CALL SYSPROC.ADMIN_CMD('REORG TABLE %s')"
+)
+
+// execQuerier executes statements and queries, it's implemented by *sql.DB and *sql.Tx.
+type execQuerier interface {
+	coltypes.Querier
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// evolveTable adds columns for the payload's fields to the table, reorganizes the table if it's left
+// in the REORG-pending state, and returns the refreshed schema of the table.
+// Types of the columns are inferred from the fields' values.
+func (w *Writer) evolveTable(
+	ctx context.Context,
+	q execQuerier,
+	table string,
+	payload sdk.StructuredData,
+	fields []string,
+) (*tableSchema, error) {
+	if err := checkTableName(table); err != nil {
+		return nil, err
+	}
+
+	for _, field := range fields {
+		if err := checkIdentifier(field); err != nil {
+			return nil, err
+		}
+	}
+
+	query := buildAddColumnsQuery(table, payload, fields)
+
+	if _, err := q.ExecContext(ctx, query); err != nil {
+		return nil, fmt.Errorf("exec add columns: %w", db2errors.WithTable(err, table))
+	}
+
+	sdk.Logger(ctx).Info().
+		Str("table", table).
+		Strs("columns", fields).
+		Msg("added columns to the table")
+
+	if err := reorgIfPending(ctx, q, table); err != nil {
+		return nil, err
+	}
+
+	schema, err := loadSchema(ctx, q, strings.ToUpper(table))
+	if err != nil {
+		return nil, fmt.Errorf("refresh table schema: %w", err)
+	}
+
+	w.schemas[strings.ToUpper(table)] = schema
+
+	return schema, nil
+}
+
+// buildAddColumnsQuery generates an SQL ALTER TABLE statement that adds columns for the fields.
+// The table and the fields must be checked to be valid identifiers.
+func buildAddColumnsQuery(table string, payload sdk.StructuredData, fields []string) string {
+	var sb strings.Builder
+
+	sb.WriteString("ALTER TABLE ")
+	sb.WriteString(table)
+
+	for _, field := range fields {
+		sb.WriteString(" ADD COLUMN ")
+		sb.WriteString(strings.ToUpper(field))
+		sb.WriteString(" ")
+		sb.WriteString(coltypes.InferType(payload[field]))
+	}
+
+	return sb.String()
+}
+
+// reorgIfPending reorganizes the table if it's in the REORG-pending state, DB2 doesn't allow
+// to access such tables.
+func reorgIfPending(ctx context.Context, q execQuerier, table string) error {
+	var pending int
+
+	rows, err := q.QueryContext(ctx, fmt.Sprintf(queryReorgPending, escapeLiteral(strings.ToUpper(table))))
+	if err != nil {
+		return fmt.Errorf("query reorg pending: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err = rows.Scan(&pending); err != nil {
+			return fmt.Errorf("scan reorg pending: %w", err)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("iterate reorg pending: %w", err)
+	}
+
+	if pending == 0 {
+		return nil
+	}
+
+	if _, err = q.ExecContext(ctx, fmt.Sprintf(queryReorgTable, escapeLiteral(table))); err != nil {
+		return fmt.Errorf("exec reorg table: %w", err)
+	}
+
+	sdk.Logger(ctx).Info().Str("table", table).Msg("reorganized the table")
+
+	return nil
+}
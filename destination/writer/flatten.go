@@ -0,0 +1,61 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"strings"
+
+	sdk "github.com/conduitio/conduit-connector-sdk"
+
+	"github.com/conduitio-labs/conduit-connector-db2/coltypes"
+)
+
+// flatten expands nested objects of the data into fields, whose names are prefixed by the names of
+// their parents and the separator, e.g. {"address": {"city": "Kyiv"}} turns into {"address_city": "Kyiv"}.
+// Objects written to the columns of string types are left as is, so they are stored as JSON strings.
+func flatten(data sdk.StructuredData, columnTypes map[string]coltypes.ColumnType, separator string) sdk.StructuredData {
+	if data == nil {
+		return nil
+	}
+
+	result := make(sdk.StructuredData, len(data))
+	flattenInto(result, data, "", columnTypes, separator)
+
+	return result
+}
+
+// flattenInto writes flattened fields of the data to the result, prefixing their names with the prefix.
+func flattenInto(
+	result, data map[string]any,
+	prefix string,
+	columnTypes map[string]coltypes.ColumnType,
+	separator string,
+) {
+	for field, value := range data {
+		name := field
+		if prefix != "" {
+			name = prefix + separator + field
+		}
+
+		nested, ok := value.(map[string]any)
+		if !ok || columnTypes[strings.ToUpper(name)].IsString() {
+			result[name] = value
+
+			continue
+		}
+
+		flattenInto(result, nested, name, columnTypes, separator)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"reflect"
+	"testing"
+
+	sdk "github.com/conduitio/conduit-connector-sdk"
+
+	"github.com/conduitio-labs/conduit-connector-db2/coltypes"
+)
+
+func Test_flatten(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		data        sdk.StructuredData
+		columnTypes map[string]coltypes.ColumnType
+		separator   string
+		want        sdk.StructuredData
+	}{
+		{
+			name:      "nil data",
+			data:      nil,
+			separator: "_",
+			want:      nil,
+		},
+		{
+			name: "nested objects",
+			data: sdk.StructuredData{
+				"id": 1,
+				"address": map[string]any{
+					"city": "Kyiv",
+					"geo":  map[string]any{"lat": 50.45, "lon": 30.52},
+				},
+				"tags": []any{"a", "b"},
+			},
+			separator: "_",
+			want: sdk.StructuredData{
+				"id":              1,
+				"address_city":    "Kyiv",
+				"address_geo_lat": 50.45,
+				"address_geo_lon": 30.52,
+				"tags":            []any{"a", "b"},
+			},
+		},
+		{
+			name: "objects of string columns are kept",
+			data: sdk.StructuredData{
+				"address": map[string]any{
+					"city": "Kyiv",
+					"geo":  map[string]any{"lat": 50.45},
+				},
+				"profile": map[string]any{"age": 30},
+			},
+			columnTypes: map[string]coltypes.ColumnType{
+				"ADDRESS__GEO": {Name: "CLOB"},
+				"PROFILE":      {Name: "XML"},
+			},
+			separator: "__",
+			want: sdk.StructuredData{
+				"address__city": "Kyiv",
+				"address__geo":  map[string]any{"lat": 50.45},
+				"profile":       map[string]any{"age": 30},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := flatten(tt.data, tt.columnTypes, tt.separator)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("flatten() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"regexp"
+	"strings"
+)
+
+// identifierPattern matches DB2 ordinary identifiers, that's the only form of names the writer puts into DDL,
+// since the other statements refer to the columns by unquoted uppercased names.
+var identifierPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{0,127}$`)
+
+// reservedWords contains SQL reserved words that can't be used as unquoted identifiers.
+var reservedWords = map[string]struct{}{
+	"ADD": {}, "ALL": {}, "ALTER": {}, "AND": {}, "ANY": {}, "AS": {}, "ASC": {}, "BETWEEN": {}, "BY": {},
+	"CASE": {}, "CAST": {}, "CHECK": {}, "COLUMN": {}, "CONSTRAINT": {}, "CREATE": {}, "CROSS": {},
+	"CURRENT": {}, "CURRENT_DATE": {}, "CURRENT_TIME": {}, "CURRENT_TIMESTAMP": {}, "CURRENT_USER": {},
+	"DEFAULT": {}, "DELETE": {}, "DESC": {}, "DISTINCT": {}, "DROP": {}, "ELSE": {}, "END": {}, "EXCEPT": {},
+	"EXISTS": {}, "FETCH": {}, "FOR": {}, "FOREIGN": {}, "FROM": {}, "FULL": {}, "GRANT": {}, "GROUP": {},
+	"HAVING": {}, "IN": {}, "INNER": {}, "INSERT": {}, "INTERSECT": {}, "INTO": {}, "IS": {}, "JOIN": {},
+	"KEY": {}, "LEFT": {}, "LIKE": {}, "NOT": {}, "NULL": {}, "OF": {}, "ON": {}, "OR": {}, "ORDER": {},
+	"OUTER": {}, "PRIMARY": {}, "REFERENCES": {}, "RIGHT": {}, "SELECT": {}, "SET": {}, "SOME": {},
+	"TABLE": {}, "THEN": {}, "TO": {}, "UNION": {}, "UNIQUE": {}, "UPDATE": {}, "USER": {}, "USING": {},
+	"VALUES": {}, "WHEN": {}, "WHERE": {}, "WITH": {},
+}
+
+// checkIdentifier returns an *InvalidIdentifierError if the name is not a DB2 ordinary identifier
+// or is a reserved word.
+func checkIdentifier(name string) error {
+	if !identifierPattern.MatchString(name) {
+		return &InvalidIdentifierError{Name: name}
+	}
+
+	if _, ok := reservedWords[strings.ToUpper(name)]; ok {
+		return &InvalidIdentifierError{Name: name}
+	}
+
+	return nil
+}
+
+// checkTableName checks the table name, that may be qualified by a schema name, e.g. "SALES.CLIENTS".
+func checkTableName(table string) error {
+	parts := strings.Split(table, ".")
+	if len(parts) > 2 {
+		return &InvalidIdentifierError{Name: table}
+	}
+
+	for _, part := range parts {
+		if err := checkIdentifier(part); err != nil {
+			return &InvalidIdentifierError{Name: table}
+		}
+	}
+
+	return nil
+}
+
+// escapeLiteral escapes single quotes of the value put into an SQL string literal.
+func escapeLiteral(value string) string {
+	return strings.ReplaceAll(value, "'", "''")
+}
@@ -0,0 +1,90 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckTableName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		table   string
+		wantErr bool
+	}{
+		{name: "table", table: "CLIENTS"},
+		{name: "qualified table", table: "SALES.CLIENTS_2022"},
+		{name: "lower case", table: "clients"},
+		{name: "space", table: "a b", wantErr: true},
+		{name: "statement", table: "x;DROP TABLE CLIENTS", wantErr: true},
+		{name: "quote", table: "X') DROP", wantErr: true},
+		{name: "reserved word", table: "select", wantErr: true},
+		{name: "leading digit", table: "1CLIENTS", wantErr: true},
+		{name: "empty schema", table: ".CLIENTS", wantErr: true},
+		{name: "too many parts", table: "A.B.C", wantErr: true},
+		{name: "too long", table: strings.Repeat("A", 129), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := checkTableName(tt.table)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkTableName() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var identifierErr *InvalidIdentifierError
+			if err != nil && !errors.As(err, &identifierErr) {
+				t.Errorf("checkTableName() error = %T, want *InvalidIdentifierError", err)
+			}
+		})
+	}
+}
+
+func TestCheckIdentifier(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		identifier string
+		wantErr    bool
+	}{
+		{name: "column", identifier: "CREATED_AT"},
+		{name: "lower case", identifier: "created_at"},
+		{name: "qualified", identifier: "A.B", wantErr: true},
+		{name: "dash", identifier: "created-at", wantErr: true},
+		{name: "reserved word", identifier: "Order", wantErr: true},
+		{name: "empty", identifier: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := checkIdentifier(tt.identifier); (err != nil) != tt.wantErr {
+				t.Errorf("checkIdentifier() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	sdk "github.com/conduitio/conduit-connector-sdk"
+	"github.com/huandu/go-sqlbuilder"
+
+	"github.com/conduitio-labs/conduit-connector-db2/coltypes"
+	"github.com/conduitio-labs/conduit-connector-db2/config"
+	"github.com/conduitio-labs/conduit-connector-db2/db2errors"
+)
+
+// LOBOptions defines how LOB values larger than the maximum inline size are written.
+type LOBOptions struct {
+	// MaxInlineSize is a maximum size in bytes of LOB values written with their rows, 0 means no limit.
+	MaxInlineSize int
+	// Policy defines what to do with larger values.
+	Policy config.LOBPolicy
+	// TruncatedColumn is a column that flags rows with truncated LOB values with 1, optional.
+	TruncatedColumn string
+}
+
+// limitLOBs applies the LOB policy to the converted payload's LOB values that are larger than the maximum inline size.
+// Chunked values are replaced by their first chunks, the remaining chunks are returned by the columns.
+func (w *Writer) limitLOBs(
+	ctx context.Context,
+	table string,
+	schema *tableSchema,
+	payload sdk.StructuredData,
+) map[string][]any {
+	if w.lob.MaxInlineSize <= 0 {
+		return nil
+	}
+
+	var (
+		chunks    map[string][]any
+		truncated bool
+		// heapAlloc is read once for the payload, when the first value exceeds the limit,
+		// since reading memory statistics stops the world.
+		heapAlloc     uint64
+		heapAllocRead bool
+	)
+
+	for column, value := range payload {
+		if !schema.columnTypes[strings.ToUpper(column)].IsLOB() {
+			continue
+		}
+
+		size := lobSize(value)
+		if size <= w.lob.MaxInlineSize {
+			continue
+		}
+
+		if !heapAllocRead {
+			heapAlloc, heapAllocRead = readHeapAlloc(), true
+		}
+
+		event := sdk.Logger(ctx).Warn()
+
+		switch w.lob.Policy {
+		case config.LOBSkip:
+			delete(payload, column)
+		case config.LOBTruncate:
+			payload[column] = splitLOB(value, w.lob.MaxInlineSize)[0]
+			truncated = true
+		default:
+			parts := splitLOB(value, w.lob.MaxInlineSize)
+
+			if chunks == nil {
+				chunks = make(map[string][]any)
+			}
+
+			payload[column], chunks[column] = parts[0], parts[1:]
+
+			event.Int("chunks", len(parts))
+		}
+
+		event.Str("table", table).
+			Str("column", column).
+			Int("size", size).
+			Int("maxInlineSize", w.lob.MaxInlineSize).
+			Uint64("heapAlloc", heapAlloc).
+			Msgf("the LOB value exceeds the maximum inline size, policy %q is applied", w.lob.Policy)
+	}
+
+	// the flag is missing if the ignore policy of unknown columns has dropped it.
+	if truncated && w.lob.TruncatedColumn != "" {
+		if name, _, ok := lookupColumn(payload, w.lob.TruncatedColumn); ok {
+			payload[name] = 1
+		}
+	}
+
+	return chunks
+}
+
+// addLOBTruncatedColumn adds the truncated LOB flag to the payload as 0, before the payload's fields are checked
+// against the table, so the flag's column is handled by the unknown columns policy and created with the table.
+// The flag is set to 1 by the limitLOBs. The payload is left untouched if it's empty.
+func (w *Writer) addLOBTruncatedColumn(payload sdk.StructuredData) {
+	if w.lob.MaxInlineSize <= 0 || w.lob.TruncatedColumn == "" || len(payload) == 0 {
+		return
+	}
+
+	if name, _, ok := lookupColumn(payload, w.lob.TruncatedColumn); ok {
+		delete(payload, name)
+	}
+
+	payload[w.lob.TruncatedColumn] = 0
+}
+
+// readHeapAlloc returns the size of the allocated heap memory.
+func readHeapAlloc() uint64 {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	return memStats.HeapAlloc
+}
+
+// appendLOBChunks appends the remaining chunks of the chunked LOB values to the columns of the row
+// matched by the match columns. DB2 rewrites the whole LOB value on every append, so the server I/O grows
+// with the square of the number of chunks, and the chunk size should be close to the largest values.
+func (w *Writer) appendLOBChunks(
+	ctx context.Context,
+	q execQuerier,
+	table string,
+	schema *tableSchema,
+	match sdk.StructuredData,
+	chunks map[string][]any,
+) error {
+	for column, columnChunks := range chunks {
+		lobType := lobCastType(schema.columnTypes[strings.ToUpper(column)])
+
+		for _, chunk := range columnChunks {
+			query, args := buildAppendLOBQuery(table, column, lobType, chunk, match)
+
+			if _, err := w.execContext(ctx, q, query, args...); err != nil {
+				return fmt.Errorf("exec append %q chunk: %w", column, db2errors.WithTable(err, table))
+			}
+		}
+	}
+
+	return nil
+}
+
+// buildAppendLOBQuery generates an SQL UPDATE statement query, that appends the chunk to the LOB column
+// of the row matched by the match columns. The columns are sorted by name.
+func buildAppendLOBQuery(table, column, lobType string, chunk any, match sdk.StructuredData) (string, []any) {
+	ub := sqlbuilder.NewUpdateBuilder()
+
+	ub.Update(table)
+	ub.Set(fmt.Sprintf("%s = %s || CAST(%s AS %s)", column, column, ub.Var(chunk), lobType))
+
+	columns := make([]string, 0, len(match))
+	for matchColumn := range match {
+		columns = append(columns, matchColumn)
+	}
+
+	sort.Strings(columns)
+
+	for _, matchColumn := range columns {
+		ub.Where(ub.Equal(matchColumn, match[matchColumn]))
+	}
+
+	query, args := ub.Build()
+
+	return query, args
+}
+
+// lobCastType returns a type the chunks of the LOB column are cast to, parameters have no types in expressions.
+func lobCastType(columnType coltypes.ColumnType) string {
+	switch columnType.Name {
+	case "BLOB":
+		return "BLOB(2G)"
+	case "DBCLOB":
+		return "DBCLOB(1G)"
+	default:
+		return "CLOB(2G)"
+	}
+}
+
+// lobSize returns a size of the LOB value in bytes.
+func lobSize(value any) int {
+	switch v := value.(type) {
+	case string:
+		return len(v)
+	case []byte:
+		return len(v)
+	default:
+		return 0
+	}
+}
+
+// splitLOB splits the LOB value into chunks of at most size bytes, strings are split without splitting characters.
+func splitLOB(value any, size int) []any {
+	var chunks []any
+
+	switch v := value.(type) {
+	case []byte:
+		for start := 0; start < len(v); start += size {
+			end := start + size
+			if end > len(v) {
+				end = len(v)
+			}
+
+			chunks = append(chunks, v[start:end])
+		}
+	case string:
+		for start := 0; start < len(v); {
+			end := start + size
+			if end >= len(v) {
+				end = len(v)
+			} else {
+				for end > start && !utf8.RuneStart(v[end]) {
+					end--
+				}
+
+				// the size is smaller than the character, so the chunk contains the whole character.
+				if end == start {
+					_, runeSize := utf8.DecodeRuneInString(v[start:])
+					end = start + runeSize
+				}
+			}
+
+			chunks = append(chunks, v[start:end])
+			start = end
+		}
+	default:
+		chunks = append(chunks, value)
+	}
+
+	return chunks
+}
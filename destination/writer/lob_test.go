@@ -0,0 +1,219 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	sdk "github.com/conduitio/conduit-connector-sdk"
+
+	"github.com/conduitio-labs/conduit-connector-db2/coltypes"
+	"github.com/conduitio-labs/conduit-connector-db2/config"
+)
+
+func TestWriter_limitLOBs(t *testing.T) {
+	t.Parallel()
+
+	schema := &tableSchema{
+		columnTypes: map[string]coltypes.ColumnType{
+			"ID":    {Name: "INTEGER"},
+			"NAME":  {Name: "VARCHAR", Length: 255},
+			"NOTES": {Name: "CLOB", Length: 1048576},
+			"PHOTO": {Name: "BLOB", Length: 1048576},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		lob         LOBOptions
+		payload     sdk.StructuredData
+		wantPayload sdk.StructuredData
+		wantChunks  map[string][]any
+	}{
+		{
+			name:        "no limit",
+			lob:         LOBOptions{Policy: config.LOBSkip},
+			payload:     sdk.StructuredData{"ID": int64(1), "NOTES": "abcdef"},
+			wantPayload: sdk.StructuredData{"ID": int64(1), "NOTES": "abcdef"},
+		},
+		{
+			name:        "chunked",
+			lob:         LOBOptions{MaxInlineSize: 4, Policy: config.LOBChunked},
+			payload:     sdk.StructuredData{"ID": int64(1), "NOTES": "abcdefghij", "PHOTO": []byte{1, 2, 3}},
+			wantPayload: sdk.StructuredData{"ID": int64(1), "NOTES": "abcd", "PHOTO": []byte{1, 2, 3}},
+			wantChunks:  map[string][]any{"NOTES": {"efgh", "ij"}},
+		},
+		{
+			name: "truncate with flag",
+			lob:  LOBOptions{MaxInlineSize: 2, Policy: config.LOBTruncate, TruncatedColumn: "TRUNCATED"},
+			payload: sdk.StructuredData{
+				"ID": int64(1), "NAME": "abcdef", "PHOTO": []byte{1, 2, 3}, "TRUNCATED": 0,
+			},
+			wantPayload: sdk.StructuredData{
+				"ID": int64(1), "NAME": "abcdef", "PHOTO": []byte{1, 2}, "TRUNCATED": 1,
+			},
+		},
+		{
+			name:        "flag of rows without truncated values",
+			lob:         LOBOptions{MaxInlineSize: 8, Policy: config.LOBTruncate, TruncatedColumn: "TRUNCATED"},
+			payload:     sdk.StructuredData{"ID": int64(1), "NOTES": "abc", "TRUNCATED": 0},
+			wantPayload: sdk.StructuredData{"ID": int64(1), "NOTES": "abc", "TRUNCATED": 0},
+		},
+		{
+			name:        "dropped flag",
+			lob:         LOBOptions{MaxInlineSize: 2, Policy: config.LOBTruncate, TruncatedColumn: "TRUNCATED"},
+			payload:     sdk.StructuredData{"ID": int64(1), "NOTES": "abc"},
+			wantPayload: sdk.StructuredData{"ID": int64(1), "NOTES": "ab"},
+		},
+		{
+			name:        "skip",
+			lob:         LOBOptions{MaxInlineSize: 2, Policy: config.LOBSkip},
+			payload:     sdk.StructuredData{"ID": int64(1), "notes": "abc", "PHOTO": nil},
+			wantPayload: sdk.StructuredData{"ID": int64(1), "PHOTO": nil},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := &Writer{lob: tt.lob}
+
+			chunks := w.limitLOBs(context.Background(), "CLIENTS", schema, tt.payload)
+			if !reflect.DeepEqual(chunks, tt.wantChunks) {
+				t.Errorf("limitLOBs() chunks = %v, want %v", chunks, tt.wantChunks)
+			}
+
+			if !reflect.DeepEqual(tt.payload, tt.wantPayload) {
+				t.Errorf("limitLOBs() payload = %v, want %v", tt.payload, tt.wantPayload)
+			}
+		})
+	}
+}
+
+func TestWriter_addLOBTruncatedColumn(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		lob         LOBOptions
+		payload     sdk.StructuredData
+		wantPayload sdk.StructuredData
+	}{
+		{
+			name:        "flag",
+			lob:         LOBOptions{MaxInlineSize: 2, TruncatedColumn: "TRUNCATED"},
+			payload:     sdk.StructuredData{"ID": 1, "truncated": 1},
+			wantPayload: sdk.StructuredData{"ID": 1, "TRUNCATED": 0},
+		},
+		{
+			name:        "no limit",
+			lob:         LOBOptions{TruncatedColumn: "TRUNCATED"},
+			payload:     sdk.StructuredData{"ID": 1},
+			wantPayload: sdk.StructuredData{"ID": 1},
+		},
+		{
+			name:        "no flag column",
+			lob:         LOBOptions{MaxInlineSize: 2},
+			payload:     sdk.StructuredData{"ID": 1},
+			wantPayload: sdk.StructuredData{"ID": 1},
+		},
+		{
+			name:        "empty payload",
+			lob:         LOBOptions{MaxInlineSize: 2, TruncatedColumn: "TRUNCATED"},
+			payload:     sdk.StructuredData{},
+			wantPayload: sdk.StructuredData{},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := &Writer{lob: tt.lob}
+
+			w.addLOBTruncatedColumn(tt.payload)
+			if !reflect.DeepEqual(tt.payload, tt.wantPayload) {
+				t.Errorf("addLOBTruncatedColumn() payload = %v, want %v", tt.payload, tt.wantPayload)
+			}
+		})
+	}
+}
+
+func TestSplitLOB(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		value any
+		size  int
+		want  []any
+	}{
+		{name: "bytes", value: []byte{1, 2, 3, 4, 5}, size: 2, want: []any{[]byte{1, 2}, []byte{3, 4}, []byte{5}}},
+		{name: "string", value: "abcdef", size: 3, want: []any{"abc", "def"}},
+		{name: "multibyte characters", value: "aїїb", size: 2, want: []any{"a", "ї", "ї", "b"}},
+		{name: "size smaller than a character", value: "їa", size: 1, want: []any{"ї", "a"}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := splitLOB(tt.value, tt.size); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitLOB() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildAppendLOBQuery(t *testing.T) {
+	t.Parallel()
+
+	query, args := buildAppendLOBQuery("CLIENTS", "NOTES", "CLOB(2G)", "efgh",
+		sdk.StructuredData{"VALID_FROM": "2022-10-01", "ID": 1})
+
+	wantQuery := "UPDATE CLIENTS SET NOTES = NOTES || CAST(? AS CLOB(2G)) WHERE ID = ? AND VALID_FROM = ?"
+	if query != wantQuery {
+		t.Errorf("buildAppendLOBQuery() query = %q, want %q", query, wantQuery)
+	}
+
+	if wantArgs := []any{"efgh", 1, "2022-10-01"}; !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("buildAppendLOBQuery() args = %v, want %v", args, wantArgs)
+	}
+}
+
+func TestWriter_lobUpdateColumns(t *testing.T) {
+	t.Parallel()
+
+	w := &Writer{lob: LOBOptions{MaxInlineSize: 4, Policy: config.LOBChunked, TruncatedColumn: "TRUNCATED"}}
+
+	got := w.lobUpdateColumns(
+		sdk.StructuredData{"ID": 1, "NAME": "John", "NOTES": "abcd", "TRUNCATED": 0},
+		[]string{"NAME", "PHOTO"},
+		map[string][]any{"NOTES": {"efgh"}},
+	)
+
+	if want := []string{"NAME", "NOTES", "TRUNCATED"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("lobUpdateColumns() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"fmt"
+	"strings"
+
+	sdk "github.com/conduitio/conduit-connector-sdk"
+
+	"github.com/conduitio-labs/conduit-connector-db2/coltypes"
+)
+
+// pathSeparator separates names of nested fields in a field path, e.g. "address.city".
+const pathSeparator = "."
+
+// FieldMapping defines how the record's fields are mapped to table columns.
+// Fields are referenced by paths, where nested fields are separated by dots, e.g. "address.city".
+type FieldMapping struct {
+	// Columns maps field paths to column names.
+	// Fields that are not in the map are written to the columns with the same names.
+	Columns map[string]string
+	// Include contains field paths to write, if it's empty all fields are written.
+	Include []string
+	// Exclude contains field paths that are not written.
+	Exclude []string
+}
+
+// isEmpty reports whether the mapping leaves the data untouched.
+func (m FieldMapping) isEmpty() bool {
+	return len(m.Columns) == 0 && len(m.Include) == 0 && len(m.Exclude) == 0
+}
+
+// validate checks that all mapped columns exist in the table.
+func (m FieldMapping) validate(columnTypes map[string]coltypes.ColumnType) error {
+	var missing []string
+
+	for _, column := range m.Columns {
+		if _, ok := columnTypes[strings.ToUpper(column)]; !ok {
+			missing = append(missing, column)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrUnknownMappedColumns, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// apply returns a copy of the data with included, not excluded and renamed fields.
+func (m FieldMapping) apply(data sdk.StructuredData) sdk.StructuredData {
+	if data == nil || m.isEmpty() {
+		return data
+	}
+
+	result := make(sdk.StructuredData, len(data))
+
+	if len(m.Include) > 0 {
+		for _, path := range m.Include {
+			if value, ok := getPath(data, path); ok {
+				setPath(result, path, value)
+			}
+		}
+	} else {
+		for field, value := range data {
+			result[field] = value
+		}
+	}
+
+	for _, path := range m.Exclude {
+		deletePath(result, path)
+	}
+
+	for path, column := range m.Columns {
+		value, ok := getPath(result, path)
+		if !ok {
+			continue
+		}
+
+		deletePath(result, path)
+		result[column] = value
+	}
+
+	return result
+}
+
+// rename returns a copy of the data with renamed fields. Unlike apply, it doesn't drop any fields,
+// so it's suitable for keys.
+func (m FieldMapping) rename(data sdk.StructuredData) sdk.StructuredData {
+	if data == nil || len(m.Columns) == 0 {
+		return data
+	}
+
+	result := make(sdk.StructuredData, len(data))
+	for field, value := range data {
+		if column, ok := m.Columns[field]; ok {
+			field = column
+		}
+
+		result[field] = value
+	}
+
+	return result
+}
+
+// getPath returns a value of the field by its path. A field whose name equals the whole path
+// takes precedence over a nested field.
+func getPath(data map[string]any, path string) (any, bool) {
+	if value, ok := data[path]; ok {
+		return value, true
+	}
+
+	head, tail, ok := strings.Cut(path, pathSeparator)
+	if !ok {
+		return nil, false
+	}
+
+	nested, ok := data[head].(map[string]any)
+	if !ok {
+		return nil, false
+	}
+
+	return getPath(nested, tail)
+}
+
+// setPath sets a value of the field by its path, creating intermediate maps if needed.
+// The intermediate maps are copied, so the source data isn't modified.
+func setPath(data map[string]any, path string, value any) {
+	head, tail, ok := strings.Cut(path, pathSeparator)
+	if !ok {
+		data[path] = value
+
+		return
+	}
+
+	if _, exists := data[path]; exists {
+		data[path] = value
+
+		return
+	}
+
+	nested := make(map[string]any)
+	if existing, ok := data[head].(map[string]any); ok {
+		for k, v := range existing {
+			nested[k] = v
+		}
+	}
+
+	data[head] = nested
+	setPath(nested, tail, value)
+}
+
+// deletePath deletes the field by its path. Nested maps are copied before deleting from them,
+// so the source data isn't modified. Nested maps that become empty are deleted too.
+func deletePath(data map[string]any, path string) {
+	if _, ok := data[path]; ok {
+		delete(data, path)
+
+		return
+	}
+
+	head, tail, ok := strings.Cut(path, pathSeparator)
+	if !ok {
+		return
+	}
+
+	nested, ok := data[head].(map[string]any)
+	if !ok {
+		return
+	}
+
+	if _, found := getPath(nested, tail); !found {
+		return
+	}
+
+	copied := make(map[string]any, len(nested))
+	for k, v := range nested {
+		copied[k] = v
+	}
+
+	deletePath(copied, tail)
+
+	// don't leave empty parents of the deleted fields behind.
+	if len(copied) == 0 {
+		delete(data, head)
+
+		return
+	}
+
+	data[head] = copied
+}
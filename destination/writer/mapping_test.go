@@ -0,0 +1,123 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	sdk "github.com/conduitio/conduit-connector-sdk"
+
+	"github.com/conduitio-labs/conduit-connector-db2/coltypes"
+)
+
+func TestFieldMapping_apply(t *testing.T) {
+	t.Parallel()
+
+	data := func() sdk.StructuredData {
+		return sdk.StructuredData{
+			"id":       1,
+			"fullName": "John Doe",
+			"password": "secret",
+			"address": map[string]any{
+				"city": "Kyiv",
+				"zip":  "01001",
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		mapping FieldMapping
+		want    sdk.StructuredData
+	}{
+		{
+			name:    "empty mapping",
+			mapping: FieldMapping{},
+			want:    data(),
+		},
+		{
+			name: "rename and nested path",
+			mapping: FieldMapping{
+				Columns: map[string]string{"fullName": "NAME", "address.city": "CITY"},
+			},
+			want: sdk.StructuredData{
+				"id":       1,
+				"NAME":     "John Doe",
+				"password": "secret",
+				"CITY":     "Kyiv",
+				"address":  map[string]any{"zip": "01001"},
+			},
+		},
+		{
+			name: "exclude",
+			mapping: FieldMapping{
+				Columns: map[string]string{"address.zip": "ZIP"},
+				Exclude: []string{"password", "address"},
+			},
+			want: sdk.StructuredData{
+				"id":       1,
+				"fullName": "John Doe",
+			},
+		},
+		{
+			name: "include",
+			mapping: FieldMapping{
+				Columns: map[string]string{"address.city": "CITY"},
+				Include: []string{"id", "address.city", "missing"},
+			},
+			want: sdk.StructuredData{
+				"id":   1,
+				"CITY": "Kyiv",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			source := data()
+
+			got := tt.mapping.apply(source)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("apply() = %v, want %v", got, tt.want)
+			}
+
+			if !reflect.DeepEqual(source, data()) {
+				t.Errorf("apply() modified the source data: %v", source)
+			}
+		})
+	}
+}
+
+func TestFieldMapping_validate(t *testing.T) {
+	t.Parallel()
+
+	columnTypes := map[string]coltypes.ColumnType{"ID": {Name: "INTEGER"}, "CITY": {Name: "VARCHAR"}}
+
+	mapping := FieldMapping{Columns: map[string]string{"address.city": "CITY"}}
+	if err := mapping.validate(columnTypes); err != nil {
+		t.Errorf("validate() error = %v", err)
+	}
+
+	mapping = FieldMapping{Columns: map[string]string{"address.zip": "ZIP"}}
+	if err := mapping.validate(columnTypes); !errors.Is(err, ErrUnknownMappedColumns) {
+		t.Errorf("validate() error = %v, want %v", err, ErrUnknownMappedColumns)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"context"
+	"database/sql"
+
+	sdk "github.com/conduitio/conduit-connector-sdk"
+)
+
+// ConnectFunc opens and pings a new connection to the database.
+type ConnectFunc func(ctx context.Context) (*sql.DB, error)
+
+// canReconnect reports whether the writer reopens lost connections.
+func (w *Writer) canReconnect() bool {
+	return w.connect != nil && w.maxReconnectAttempts > 0
+}
+
+// reconnect replaces the lost connection with a new one, trying to open it up to the maximum number
+// of reconnect attempts, delayed by the retry backoff. The schema cache is dropped, since the tables
+// may have been changed while the connection was lost.
+func (w *Writer) reconnect(ctx context.Context) error {
+	var err error
+
+	for attempt := 1; attempt <= w.maxReconnectAttempts; attempt++ {
+		var db *sql.DB
+
+		db, err = w.connect(ctx)
+		if err == nil {
+			// the connections of the old pool are dead, so the error is not relevant.
+			if closeErr := w.db.Close(); closeErr != nil {
+				sdk.Logger(ctx).Debug().Msgf("close lost connection: %v", closeErr)
+			}
+
+			w.db = db
+			w.schemas = make(map[string]*tableSchema)
+
+			sdk.Logger(ctx).Info().Int("attempt", attempt).Msg("reconnected to db2")
+
+			return nil
+		}
+
+		sdk.Logger(ctx).Warn().Err(err).Int("attempt", attempt).Msg("reconnect to db2")
+
+		if attempt < w.maxReconnectAttempts && !sleep(ctx, w.retryOptions.backoff(attempt)) {
+			break
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,129 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	sdk "github.com/conduitio/conduit-connector-sdk"
+
+	"github.com/conduitio-labs/conduit-connector-db2/db2errors"
+)
+
+// RetryOptions defines how statements and transactions failed with transient errors are retried.
+type RetryOptions struct {
+	// MaxAttempts is a maximum number of attempts, 0 and 1 disable retries.
+	MaxAttempts int
+	// InitialBackoff is a delay before the first retry, it's doubled for every next retry.
+	InitialBackoff time.Duration
+	// MaxBackoff is a maximum delay between retries.
+	MaxBackoff time.Duration
+	// MaxElapsedTime is a total time budget of the attempts, 0 means no limit.
+	MaxElapsedTime time.Duration
+}
+
+// retry calls the fn until it succeeds, fails with an error that is not transient, or the attempts
+// or the time budget run out. Retries are delayed by the exponential backoff with jitter.
+// If the connection is lost, it's reopened before the fn is called again, the first call after
+// a reconnect is made even if the attempts have run out.
+// The fn must be a whole statement or transaction, since transient errors may roll back the transaction.
+func (w *Writer) retry(ctx context.Context, operation string, fn func() error) error {
+	start := time.Now()
+	reconnected := false
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		lost := db2errors.IsConnectionLost(err) && w.canReconnect()
+
+		if lost && !reconnected {
+			reconnected = true
+
+			if reconnectErr := w.reconnect(ctx); reconnectErr != nil {
+				return fmt.Errorf("%w (reconnect: %v)", err, reconnectErr)
+			}
+
+			// the call after the reconnect is not counted as an attempt.
+			attempt--
+
+			continue
+		}
+
+		if attempt >= w.retryOptions.MaxAttempts || !db2errors.IsTransient(err) {
+			return err
+		}
+
+		delay := w.retryOptions.backoff(attempt)
+
+		if w.retryOptions.MaxElapsedTime > 0 && time.Since(start)+delay > w.retryOptions.MaxElapsedTime {
+			return err
+		}
+
+		sdk.Logger(ctx).Warn().
+			Err(err).
+			Int("attempt", attempt).
+			Dur("delay", delay).
+			Msgf("%s failed with a transient error, retrying", operation)
+
+		if !sleep(ctx, delay) {
+			return err
+		}
+
+		if lost {
+			if reconnectErr := w.reconnect(ctx); reconnectErr != nil {
+				return fmt.Errorf("%w (reconnect: %v)", err, reconnectErr)
+			}
+		}
+	}
+}
+
+// sleep waits for the delay, it returns false if the context is done earlier.
+func sleep(ctx context.Context, delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// backoff returns a delay before the retry that follows the attempt. The exponential delay is capped
+// by the MaxBackoff, then a random jitter of up to a half of it is subtracted, so concurrent writers
+// that deadlocked each other don't retry at the same time.
+func (o RetryOptions) backoff(attempt int) time.Duration {
+	delay := o.InitialBackoff
+	for i := 1; i < attempt && delay < o.MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	if o.MaxBackoff > 0 && delay > o.MaxBackoff {
+		delay = o.MaxBackoff
+	}
+
+	if half := int64(delay / 2); half > 0 {
+		delay -= time.Duration(rand.Int63n(half + 1)) //nolint:gosec // the jitter doesn't need a secure random
+	}
+
+	return delay
+}
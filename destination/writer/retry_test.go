@@ -0,0 +1,235 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+)
+
+var (
+	errTestDeadlock     = errors.New("SQL0911N  The current transaction has been rolled back.  SQLSTATE=40001")
+	errTestDuplicateKey = errors.New("SQL0803N  One or more values in the INSERT statement are not valid.  SQLSTATE=23505")
+	errTestConnection   = errors.New("SQL30081N  A communication error has been detected.  SQLSTATE=08001")
+)
+
+// testConnector is a connector of databases that are never queried.
+type testConnector struct{}
+
+func (testConnector) Connect(context.Context) (driver.Conn, error) {
+	return nil, driver.ErrSkip
+}
+
+func (testConnector) Driver() driver.Driver {
+	return nil
+}
+
+func TestWriter_retry(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		retry        RetryOptions
+		errs         []error
+		wantAttempts int
+		wantErr      error
+	}{
+		{
+			name:         "success after transient errors",
+			retry:        RetryOptions{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond},
+			errs:         []error{errTestDeadlock, errTestDeadlock, nil},
+			wantAttempts: 3,
+		},
+		{
+			name:         "attempts run out",
+			retry:        RetryOptions{MaxAttempts: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond},
+			errs:         []error{errTestDeadlock, errTestDeadlock, nil},
+			wantAttempts: 2,
+			wantErr:      errTestDeadlock,
+		},
+		{
+			name:         "not transient error",
+			retry:        RetryOptions{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond},
+			errs:         []error{errTestDuplicateKey, nil},
+			wantAttempts: 1,
+			wantErr:      errTestDuplicateKey,
+		},
+		{
+			name: "time budget runs out",
+			retry: RetryOptions{
+				MaxAttempts: 3, InitialBackoff: time.Second, MaxBackoff: time.Second, MaxElapsedTime: time.Millisecond,
+			},
+			errs:         []error{errTestDeadlock, nil},
+			wantAttempts: 1,
+			wantErr:      errTestDeadlock,
+		},
+		{
+			name:         "retries disabled",
+			errs:         []error{errTestDeadlock, nil},
+			wantAttempts: 1,
+			wantErr:      errTestDeadlock,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := &Writer{retryOptions: tt.retry}
+
+			var attempts int
+
+			err := w.retry(context.Background(), "test", func() error {
+				attempts++
+
+				return tt.errs[attempts-1]
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("retry() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if attempts != tt.wantAttempts {
+				t.Errorf("retry() attempts = %d, want %d", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestRetryOptions_backoff(t *testing.T) {
+	t.Parallel()
+
+	opts := RetryOptions{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	tests := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{attempt: 1, max: 100 * time.Millisecond},
+		{attempt: 2, max: 200 * time.Millisecond},
+		{attempt: 4, max: 800 * time.Millisecond},
+		{attempt: 5, max: time.Second},
+		{attempt: 100, max: time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := opts.backoff(tt.attempt); got < tt.max/2 || got > tt.max {
+			t.Errorf("backoff(%d) = %s, want within [%s, %s]", tt.attempt, got, tt.max/2, tt.max)
+		}
+	}
+}
+
+func TestWriter_retry_reconnect(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		connectErrs    []error
+		errs           []error
+		wantConnects   int
+		wantAttempts   int
+		wantErr        error
+		wantReconnects bool
+	}{
+		{
+			name:           "reconnect without retries",
+			connectErrs:    []error{nil},
+			errs:           []error{errTestConnection, nil},
+			wantConnects:   1,
+			wantAttempts:   2,
+			wantReconnects: true,
+		},
+		{
+			name:           "reconnect after failed attempts",
+			connectErrs:    []error{errTestConnection, nil},
+			errs:           []error{errTestConnection, nil},
+			wantConnects:   2,
+			wantAttempts:   2,
+			wantReconnects: true,
+		},
+		{
+			name:         "reconnect attempts run out",
+			connectErrs:  []error{errTestConnection, errTestConnection},
+			errs:         []error{errTestConnection, nil},
+			wantConnects: 2,
+			wantAttempts: 1,
+			wantErr:      errTestConnection,
+		},
+		{
+			name:           "connection lost after the reconnect",
+			connectErrs:    []error{nil},
+			errs:           []error{errTestConnection, errTestConnection, nil},
+			wantConnects:   1,
+			wantAttempts:   2,
+			wantErr:        errTestConnection,
+			wantReconnects: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			lostDB := sql.OpenDB(testConnector{})
+
+			var connects int
+
+			w := &Writer{
+				db:      lostDB,
+				schemas: map[string]*tableSchema{"CLIENTS": {}},
+				retryOptions: RetryOptions{
+					MaxAttempts: 1, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond,
+				},
+				connect: func(context.Context) (*sql.DB, error) {
+					connects++
+
+					if err := tt.connectErrs[connects-1]; err != nil {
+						return nil, err
+					}
+
+					return sql.OpenDB(testConnector{}), nil
+				},
+				maxReconnectAttempts: 2,
+			}
+
+			var attempts int
+
+			err := w.retry(context.Background(), "test", func() error {
+				attempts++
+
+				return tt.errs[attempts-1]
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("retry() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if attempts != tt.wantAttempts || connects != tt.wantConnects {
+				t.Errorf("retry() attempts = %d, connects = %d, want %d, %d",
+					attempts, connects, tt.wantAttempts, tt.wantConnects)
+			}
+
+			if reconnected := w.db != lostDB && len(w.schemas) == 0; reconnected != tt.wantReconnects {
+				t.Errorf("retry() reconnected = %v, want %v", reconnected, tt.wantReconnects)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	sdk "github.com/conduitio/conduit-connector-sdk"
+
+	"github.com/conduitio-labs/conduit-connector-db2/coltypes"
+	"github.com/conduitio-labs/conduit-connector-db2/config"
+)
+
+// tableSchema contains catalog information about a table.
+type tableSchema struct {
+	// columnTypes maps column names to their DB2 types.
+	columnTypes map[string]coltypes.ColumnType
+	// generatedColumns contains columns whose values are always generated by the database.
+	generatedColumns map[string]struct{}
+	// droppedFields contains unknown fields that have already been logged as dropped.
+	droppedFields map[string]struct{}
+}
+
+// getSchema returns the schema of the table. Schemas are loaded from the catalog once and cached.
+func (w *Writer) getSchema(ctx context.Context, table string) (*tableSchema, error) {
+	table = strings.ToUpper(table)
+
+	if schema, ok := w.schemas[table]; ok {
+		return schema, nil
+	}
+
+	schema, err := loadSchema(ctx, w.db, table)
+	if err != nil {
+		return nil, err
+	}
+
+	w.schemas[table] = schema
+
+	return schema, nil
+}
+
+// loadSchema loads the schema of the table from the catalog.
+func loadSchema(ctx context.Context, querier coltypes.Querier, table string) (*tableSchema, error) {
+	columnTypes, err := coltypes.GetColumnTypes(ctx, querier, table)
+	if err != nil {
+		return nil, fmt.Errorf("get column types: %w", err)
+	}
+
+	generatedColumns, err := coltypes.GetGeneratedAlwaysColumns(ctx, querier, table)
+	if err != nil {
+		return nil, fmt.Errorf("get generated always columns: %w", err)
+	}
+
+	return &tableSchema{
+		columnTypes:      columnTypes,
+		generatedColumns: generatedColumns,
+		droppedFields:    make(map[string]struct{}),
+	}, nil
+}
+
+// isGenerated reports whether the column's values are always generated by the database.
+func (s *tableSchema) isGenerated(column string) bool {
+	_, ok := s.generatedColumns[strings.ToUpper(column)]
+
+	return ok
+}
+
+// omitGenerated deletes the columns whose values are always generated by the database from the payload.
+func (s *tableSchema) omitGenerated(payload sdk.StructuredData) {
+	for column := range payload {
+		if s.isGenerated(column) {
+			delete(payload, column)
+		}
+	}
+}
+
+// checkUnknownColumns handles the payload's fields that don't exist in the table according to the policy
+// and returns the schema of the table, which is refreshed if the table has been evolved.
+// The fail policy returns an *UnknownColumnsError, the ignore policy drops the fields from the payload
+// and logs each of them once per table, the evolve policy adds columns for the fields to the table.
+// The check is skipped if the catalog has no columns of the table.
+func (w *Writer) checkUnknownColumns(
+	ctx context.Context,
+	q execQuerier,
+	table string,
+	schema *tableSchema,
+	payload sdk.StructuredData,
+) (*tableSchema, error) {
+	if len(schema.columnTypes) == 0 {
+		return schema, nil
+	}
+
+	var unknown []string
+
+	for field := range payload {
+		if _, ok := schema.columnTypes[strings.ToUpper(field)]; !ok {
+			unknown = append(unknown, field)
+		}
+	}
+
+	if len(unknown) == 0 {
+		return schema, nil
+	}
+
+	sort.Strings(unknown)
+
+	switch w.unknownColumns {
+	case config.UnknownColumnsIgnore:
+	case config.UnknownColumnsEvolve:
+		evolved, err := w.evolveTable(ctx, q, table, payload, unknown)
+		if err != nil {
+			return nil, fmt.Errorf("evolve table: %w", err)
+		}
+
+		return evolved, nil
+	default:
+		return nil, &UnknownColumnsError{Table: table, Columns: unknown}
+	}
+
+	for _, field := range unknown {
+		delete(payload, field)
+
+		if _, ok := schema.droppedFields[field]; ok {
+			continue
+		}
+
+		schema.droppedFields[field] = struct{}{}
+
+		sdk.Logger(ctx).Warn().
+			Str("table", table).
+			Str("field", field).
+			Msg("the field doesn't exist in the table and is dropped")
+	}
+
+	return schema, nil
+}
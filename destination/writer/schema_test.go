@@ -0,0 +1,108 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	sdk "github.com/conduitio/conduit-connector-sdk"
+
+	"github.com/conduitio-labs/conduit-connector-db2/coltypes"
+	"github.com/conduitio-labs/conduit-connector-db2/config"
+)
+
+func TestWriter_checkUnknownColumns(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		policy      config.UnknownColumnsPolicy
+		columnTypes map[string]coltypes.ColumnType
+		payload     sdk.StructuredData
+		wantPayload sdk.StructuredData
+		wantColumns []string
+	}{
+		{
+			name:        "known columns",
+			policy:      config.UnknownColumnsFail,
+			columnTypes: map[string]coltypes.ColumnType{"ID": {Name: "INTEGER"}, "NAME": {Name: "VARCHAR"}},
+			payload:     sdk.StructuredData{"id": 1, "NAME": "Bob"},
+			wantPayload: sdk.StructuredData{"id": 1, "NAME": "Bob"},
+		},
+		{
+			name:        "fail",
+			policy:      config.UnknownColumnsFail,
+			columnTypes: map[string]coltypes.ColumnType{"ID": {Name: "INTEGER"}},
+			payload:     sdk.StructuredData{"id": 1, "name": "Bob", "age": 42},
+			wantPayload: sdk.StructuredData{"id": 1, "name": "Bob", "age": 42},
+			wantColumns: []string{"age", "name"},
+		},
+		{
+			name:        "ignore",
+			policy:      config.UnknownColumnsIgnore,
+			columnTypes: map[string]coltypes.ColumnType{"ID": {Name: "INTEGER"}},
+			payload:     sdk.StructuredData{"id": 1, "name": "Bob"},
+			wantPayload: sdk.StructuredData{"id": 1},
+		},
+		{
+			name:        "no columns in the catalog",
+			policy:      config.UnknownColumnsFail,
+			payload:     sdk.StructuredData{"id": 1},
+			wantPayload: sdk.StructuredData{"id": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := &Writer{unknownColumns: tt.policy}
+			schema := &tableSchema{columnTypes: tt.columnTypes, droppedFields: make(map[string]struct{})}
+
+			_, err := w.checkUnknownColumns(context.Background(), nil, "CLIENTS", schema, tt.payload)
+
+			var unknownErr *UnknownColumnsError
+			if errors.As(err, &unknownErr) {
+				if !reflect.DeepEqual(unknownErr.Columns, tt.wantColumns) {
+					t.Errorf("checkUnknownColumns() columns = %v, want %v", unknownErr.Columns, tt.wantColumns)
+				}
+			} else if err != nil || tt.wantColumns != nil {
+				t.Fatalf("checkUnknownColumns() error = %v, want columns %v", err, tt.wantColumns)
+			}
+
+			if !reflect.DeepEqual(tt.payload, tt.wantPayload) {
+				t.Errorf("checkUnknownColumns() payload = %v, want %v", tt.payload, tt.wantPayload)
+			}
+		})
+	}
+}
+
+func TestBuildAddColumnsQuery(t *testing.T) {
+	t.Parallel()
+
+	payload := sdk.StructuredData{"id": 1, "name": "Bob", "createdAt": "2022-10-01T12:00:00Z"}
+
+	got := buildAddColumnsQuery("CLIENTS", payload, []string{"createdAt", "name"})
+	want := "ALTER TABLE CLIENTS ADD COLUMN CREATEDAT TIMESTAMP ADD COLUMN NAME VARCHAR(255)"
+
+	if got != want {
+		t.Errorf("buildAddColumnsQuery() = %v, want %v", got, want)
+	}
+}
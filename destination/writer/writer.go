@@ -15,16 +15,23 @@
 package writer
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"sort"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	sdk "github.com/conduitio/conduit-connector-sdk"
 	"github.com/huandu/go-sqlbuilder"
 
 	"github.com/conduitio-labs/conduit-connector-db2/coltypes"
+	"github.com/conduitio-labs/conduit-connector-db2/config"
+	"github.com/conduitio-labs/conduit-connector-db2/db2errors"
 )
 
 const (
@@ -33,42 +40,152 @@ const (
 
 	// placeholder.
 	placeholder = "?"
+	// xmlPlaceholder is a placeholder of XML documents, DB2 doesn't cast parameters of VALUES clauses to XML.
+	xmlPlaceholder = "XMLPARSE(DOCUMENT CAST(? AS CLOB(2G)) PRESERVE WHITESPACE)"
+
+	// maxPlaceholders is a maximum number of parameter markers DB2 allows in a single statement.
+	maxPlaceholders = 32767
 )
 
 // Writer implements a writer logic for db2 destination.
 type Writer struct {
-	db          *sql.DB
-	table       string
-	keyColumn   string
-	columnTypes map[string]string
+	db        *sql.DB
+	table     string
+	keyColumn string
+	// schemas caches schemas of the tables the records are written to, by uppercased table names.
+	schemas map[string]*tableSchema
+	// versionColumn is a column used to skip stale updates and deletes, optional.
+	versionColumn string
+	// staleRecords is a number of records skipped because the stored row has a newer version.
+	staleRecords uint64
+	// partialUpdate enables updating only changed columns of records that contain both payloads.
+	partialUpdate bool
+	// keepMissingFields leaves columns of fields missing in the payload after the change unchanged,
+	// instead of setting them to NULL, during a partial update.
+	keepMissingFields bool
+	// scd2 contains column names used by the slowly changing dimension write mode.
+	scd2 SCD2Columns
+	// auditColumns maps audit columns to the sources of their values in a record.
+	auditColumns map[string]string
+	// fieldMapping defines how the record's fields are mapped to table columns.
+	fieldMapping FieldMapping
+	// flatten enables expanding nested objects into columns prefixed by their names.
+	flatten bool
+	// flattenSeparator separates names of the flattened nested objects and their fields.
+	flattenSeparator string
+	// unknownColumns defines what to do with payload fields that don't exist in the table.
+	unknownColumns config.UnknownColumnsPolicy
+	// autoCreateTable enables creating tables that don't exist from the records.
+	autoCreateTable bool
+	// convertOptions configures conversions of the records' values to the column types.
+	convertOptions coltypes.Options
+	// lob defines how LOB values larger than the maximum inline size are written.
+	lob LOBOptions
+	// retryOptions defines how statements and transactions failed with transient errors are retried.
+	retryOptions RetryOptions
+	// connect opens a new connection to replace the lost one, optional.
+	connect ConnectFunc
+	// maxReconnectAttempts is a maximum number of attempts to reopen the lost connection, 0 disables reconnects.
+	maxReconnectAttempts int
+	// deadLetterTable is a table records that can't be written are written to, optional.
+	deadLetterTable string
+	// queryTimeout limits the execution time of every statement that writes records, 0 means no limit.
+	queryTimeout time.Duration
+}
+
+// SCD2Columns contains column names of a slowly changing dimension (Type 2) table.
+type SCD2Columns struct {
+	// ValidFrom is a column with the time since which a version of the row is valid.
+	ValidFrom string
+	// ValidTo is a column with the time until which a version of the row is valid,
+	// it's NULL for the current version.
+	ValidTo string
+	// Current is a column that flags the current version of the row with 1, and closed ones with 0.
+	Current string
 }
 
 // Params is an incoming params for the NewWriter function.
 type Params struct {
-	DB        *sql.DB
-	Table     string
-	KeyColumn string
+	DB                   *sql.DB
+	Table                string
+	KeyColumn            string
+	VersionColumn        string
+	PartialUpdate        bool
+	KeepMissingFields    bool
+	SCD2                 SCD2Columns
+	AuditColumns         map[string]string
+	FieldMapping         FieldMapping
+	Flatten              bool
+	FlattenSeparator     string
+	UnknownColumns       config.UnknownColumnsPolicy
+	AutoCreateTable      bool
+	ConvertOptions       coltypes.Options
+	LOB                  LOBOptions
+	Retry                RetryOptions
+	Connect              ConnectFunc
+	MaxReconnectAttempts int
+	DeadLetterTable      string
+	QueryTimeout         time.Duration
 }
 
 // NewWriter creates new instance of the Writer.
 func NewWriter(ctx context.Context, params Params) (*Writer, error) {
 	writer := &Writer{
-		db:        params.DB,
-		table:     params.Table,
-		keyColumn: params.KeyColumn,
+		db:                   params.DB,
+		table:                params.Table,
+		keyColumn:            params.KeyColumn,
+		versionColumn:        params.VersionColumn,
+		partialUpdate:        params.PartialUpdate,
+		keepMissingFields:    params.KeepMissingFields,
+		scd2:                 params.SCD2,
+		auditColumns:         params.AuditColumns,
+		fieldMapping:         params.FieldMapping,
+		flatten:              params.Flatten,
+		flattenSeparator:     params.FlattenSeparator,
+		unknownColumns:       params.UnknownColumns,
+		autoCreateTable:      params.AutoCreateTable,
+		convertOptions:       params.ConvertOptions,
+		lob:                  params.LOB,
+		retryOptions:         params.Retry,
+		connect:              params.Connect,
+		maxReconnectAttempts: params.MaxReconnectAttempts,
+		deadLetterTable:      params.DeadLetterTable,
+		queryTimeout:         params.QueryTimeout,
+		schemas:              make(map[string]*tableSchema),
 	}
 
-	columnTypes, err := coltypes.GetColumnTypes(ctx, writer.db, writer.table)
+	schema, err := writer.getSchema(ctx, writer.table)
 	if err != nil {
-		return nil, fmt.Errorf("get column types: %w", err)
+		return nil, fmt.Errorf("get table schema: %w", err)
+	}
+
+	// the table will be created with the mapped columns.
+	tableMissing := len(schema.columnTypes) == 0 && writer.autoCreateTable
+
+	if err = writer.fieldMapping.validate(schema.columnTypes); err != nil && !tableMissing {
+		return nil, fmt.Errorf("validate field mapping: %w", err)
+	}
+
+	if writer.deadLetterTable != "" {
+		if err = writer.createDeadLetterTable(ctx); err != nil {
+			return nil, fmt.Errorf("create dead-letter table: %w", err)
+		}
 	}
-	writer.columnTypes = columnTypes
 
 	return writer, nil
 }
 
+// StaleRecords returns a number of records skipped because the stored row has a newer version.
+func (w *Writer) StaleRecords() uint64 {
+	return atomic.LoadUint64(&w.staleRecords)
+}
+
 // Close closes the underlying db connection.
 func (w *Writer) Close(ctx context.Context) error {
+	if staleRecords := w.StaleRecords(); staleRecords > 0 {
+		sdk.Logger(ctx).Info().Uint64("count", staleRecords).Msg("stale records skipped")
+	}
+
 	return w.db.Close()
 }
 
@@ -77,7 +194,7 @@ func (w *Writer) Close(ctx context.Context) error {
 func (w *Writer) Delete(ctx context.Context, record sdk.Record) error {
 	tableName := w.getTableName(record.Metadata)
 
-	key, err := w.structurizeData(record.Key)
+	key, err := w.structurizeKey(record.Key)
 	if err != nil {
 		return fmt.Errorf("structurize key: %w", err)
 	}
@@ -93,11 +210,131 @@ func (w *Writer) Delete(ctx context.Context, record sdk.Record) error {
 		return ErrEmptyKey
 	}
 
-	query, args := w.buildDeleteQuery(tableName, keyColumn, keyValue)
+	var (
+		versionValue any
+		hasVersion   bool
+	)
+
+	if w.versionColumn != "" {
+		schema, err := w.getSchema(ctx, tableName)
+		if err != nil {
+			return fmt.Errorf("get table schema: %w", err)
+		}
 
-	_, err = w.db.ExecContext(ctx, query, args...)
+		versionValue, hasVersion, err = w.getDeleteVersion(ctx, schema, record)
+		if err != nil {
+			return fmt.Errorf("get version: %w", err)
+		}
+
+		if !hasVersion {
+			sdk.Logger(ctx).Debug().Msgf("delete record has no %q value, version check is skipped", w.versionColumn)
+		}
+	}
+
+	var (
+		query string
+		args  []any
+	)
+
+	if hasVersion {
+		query, args = w.buildVersionedDeleteQuery(tableName, keyColumn, keyValue, versionValue)
+	} else {
+		query, args = w.buildDeleteQuery(tableName, keyColumn, keyValue)
+	}
+
+	var res sql.Result
+
+	err = w.retry(ctx, "delete", func() (err error) {
+		res, err = w.execContext(ctx, w.db, query, args...)
+
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("exec delete: %w", err)
+		return fmt.Errorf("exec delete: %w", db2errors.WithTable(err, tableName))
+	}
+
+	if hasVersion {
+		w.checkStale(ctx, res, tableName, keyValue)
+	}
+
+	return nil
+}
+
+// getDeleteVersion returns a version value of a delete record. The version is looked up
+// in the payload before the change, then in the payload after the change.
+func (w *Writer) getDeleteVersion(ctx context.Context, schema *tableSchema, record sdk.Record) (any, bool, error) {
+	for _, data := range []sdk.Data{record.Payload.Before, record.Payload.After} {
+		payload, err := w.structurizePayload(schema, data)
+		if err != nil {
+			return nil, false, fmt.Errorf("structurize payload: %w", err)
+		}
+
+		payload, err = coltypes.ConvertStructureData(ctx, schema.columnTypes, payload, w.convertOptions)
+		if err != nil {
+			return nil, false, fmt.Errorf("convert structure data: %w", err)
+		}
+
+		if _, value, ok := lookupColumn(payload, w.versionColumn); ok && value != nil {
+			return value, true, nil
+		}
+	}
+
+	return nil, false, nil
+}
+
+// execContext executes the statement by the q, the execution is limited by the query timeout, if it's set.
+func (w *Writer) execContext(ctx context.Context, q execQuerier, query string, args ...any) (sql.Result, error) {
+	if w.queryTimeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, w.queryTimeout)
+		defer cancel()
+	}
+
+	return q.ExecContext(ctx, query, args...)
+}
+
+// checkStale counts and logs a record as stale if the versioned statement hasn't affected any rows.
+func (w *Writer) checkStale(ctx context.Context, res sql.Result, table string, keyValue any) {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		sdk.Logger(ctx).Debug().Msgf("get rows affected: %v", err)
+
+		return
+	}
+
+	if affected > 0 {
+		return
+	}
+
+	total := atomic.AddUint64(&w.staleRecords, 1)
+
+	sdk.Logger(ctx).Info().
+		Str("table", table).
+		Interface("key", keyValue).
+		Uint64("total", total).
+		Msg("skipped stale record, the stored row is newer or missing")
+}
+
+// addAuditColumns adds values of the audit columns, taken from the record, to the payload.
+// Audit columns take precedence over the payload's fields with the same names.
+// The payload is left untouched if it's empty.
+func (w *Writer) addAuditColumns(record sdk.Record, payload sdk.StructuredData) error {
+	if len(payload) == 0 {
+		return nil
+	}
+
+	for column, source := range w.auditColumns {
+		value, err := getAuditValue(record, source)
+		if err != nil {
+			return fmt.Errorf("get %q value: %w", column, err)
+		}
+
+		if name, _, ok := lookupColumn(payload, column); ok {
+			delete(payload, name)
+		}
+
+		payload[column] = value
 	}
 
 	return nil
@@ -114,27 +351,43 @@ func (w *Writer) getTableName(metadata map[string]string) string {
 	return tableName
 }
 
-// Upsert inserts or updates a record. If the record.Key is not empty the method
-// will try to update the existing row, otherwise, it will plainly append a new row.
+// Upsert inserts or updates a record. The existing row is matched by the key column,
+// which is taken from the record.Key or, if it's empty, from the configured key.
+// Use Insert to plainly append rows.
 func (w *Writer) Upsert(ctx context.Context, record sdk.Record) error {
 	tableName := w.getTableName(record.Metadata)
 
-	payload, err := w.structurizeData(record.Payload.After)
+	schema, err := w.getSchema(ctx, tableName)
 	if err != nil {
-		return fmt.Errorf("structurize payload: %w", err)
+		return fmt.Errorf("get table schema: %w", err)
 	}
 
-	payload, err = coltypes.ConvertStructureData(ctx, w.columnTypes, payload)
+	payload, err := w.structurizePayload(schema, record.Payload.After)
 	if err != nil {
-		return fmt.Errorf("convert structure data: %w", err)
+		return fmt.Errorf("structurize payload: %w", err)
+	}
+
+	if err = w.addAuditColumns(record, payload); err != nil {
+		return fmt.Errorf("add audit columns: %w", err)
 	}
 
+	w.addLOBTruncatedColumn(payload)
+
 	// if payload is empty return empty payload error
 	if payload == nil {
 		return ErrEmptyPayload
 	}
 
-	key, err := w.structurizeData(record.Key)
+	// nil means that all columns are updated.
+	var changedColumns []string
+	if w.partialUpdate {
+		changedColumns, err = w.diffPayload(schema, record.Payload.Before, payload)
+		if err != nil {
+			return fmt.Errorf("diff payload: %w", err)
+		}
+	}
+
+	key, err := w.structurizeKey(record.Key)
 	if err != nil {
 		// if the key is not structured, we simply ignore it
 		// we'll try to insert just a payload in this case
@@ -153,21 +406,592 @@ func (w *Writer) Upsert(ctx context.Context, record sdk.Record) error {
 		}
 	}
 
+	schema, err = w.createTable(ctx, w.db, tableName, schema, inferColumnTypes(payload), []string{keyColumn})
+	if err != nil {
+		return fmt.Errorf("create table: %w", err)
+	}
+
+	schema, err = w.checkUnknownColumns(ctx, w.db, tableName, schema, payload)
+	if err != nil {
+		return fmt.Errorf("check unknown columns: %w", err)
+	}
+
+	// drop the changed columns that have been dropped from the payload as unknown.
+	if changedColumns != nil {
+		known := make([]string, 0, len(changedColumns))
+		for _, column := range changedColumns {
+			if _, ok := payload[column]; ok {
+				known = append(known, column)
+			}
+		}
+
+		changedColumns = known
+	}
+
+	payload, err = coltypes.ConvertStructureData(ctx, schema.columnTypes, payload, w.convertOptions)
+	if err != nil {
+		return fmt.Errorf("convert structure data: %w", err)
+	}
+
+	if w.versionColumn != "" {
+		if _, value, ok := lookupColumn(payload, w.versionColumn); !ok || value == nil {
+			return fmt.Errorf("%w: %q", ErrEmptyVersion, w.versionColumn)
+		}
+	}
+
+	chunks := w.limitLOBs(ctx, tableName, schema, payload)
+
 	columns, values := w.extractColumnsAndValues(payload)
 
-	query, err := w.buildUpsertQuery(tableName, keyColumn, columns, values)
+	updateColumns := columns
+	if changedColumns != nil {
+		updateColumns = w.lobUpdateColumns(payload, changedColumns, chunks)
+	}
+
+	query, err := w.buildUpsertQuery(tableName, keyColumn, columns, updateColumns, values)
 	if err != nil {
 		return fmt.Errorf("build upsert query: %w", err)
 	}
 
-	_, err = w.db.ExecContext(ctx, query, values...)
+	if len(chunks) > 0 {
+		return w.retry(ctx, "upsert", func() error {
+			return w.upsertChunked(ctx, tableName, schema, keyColumn, payload, query, values, chunks)
+		})
+	}
+
+	var res sql.Result
+
+	err = w.retry(ctx, "upsert", func() (err error) {
+		res, err = w.execContext(ctx, w.db, query, values...)
+
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("exec upsert: %w", err)
+		return fmt.Errorf("exec upsert: %w", db2errors.WithTable(err, tableName))
+	}
+
+	if w.versionColumn != "" && len(updateColumns) > 0 {
+		w.checkStale(ctx, res, tableName, payload[keyColumn])
 	}
 
 	return nil
 }
 
+// upsertChunked executes the upsert query and appends the remaining chunks of the chunked LOB values
+// to the written row within a single transaction. Chunks are not appended if the row is left unchanged.
+func (w *Writer) upsertChunked(
+	ctx context.Context,
+	tableName string,
+	schema *tableSchema,
+	keyColumn string,
+	payload sdk.StructuredData,
+	query string,
+	values []any,
+	chunks map[string][]any,
+) error {
+	_, keyValue, ok := lookupColumn(payload, keyColumn)
+	if !ok {
+		return ErrEmptyKey
+	}
+
+	tx, err := w.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // the rollback after the commit does nothing
+
+	res, err := w.execContext(ctx, tx, query, values...)
+	if err != nil {
+		return fmt.Errorf("exec upsert: %w", db2errors.WithTable(err, tableName))
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if affected == 0 {
+		if w.versionColumn != "" {
+			w.checkStale(ctx, res, tableName, keyValue)
+		}
+
+		return nil
+	}
+
+	match := sdk.StructuredData{keyColumn: keyValue}
+	if err = w.appendLOBChunks(ctx, tx, tableName, schema, match, chunks); err != nil {
+		return fmt.Errorf("append lob chunks: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", db2errors.WithTable(err, tableName))
+	}
+
+	return nil
+}
+
+// lobUpdateColumns returns the sorted changed columns that are left in the payload, along with
+// the columns of the chunked LOB values and the truncated LOB flag, which are always rewritten.
+func (w *Writer) lobUpdateColumns(
+	payload sdk.StructuredData,
+	changedColumns []string,
+	chunks map[string][]any,
+) []string {
+	if w.lob.MaxInlineSize <= 0 {
+		return changedColumns
+	}
+
+	columns := make(map[string]struct{}, len(changedColumns)+len(chunks)+1)
+
+	for _, column := range changedColumns {
+		if _, ok := payload[column]; ok {
+			columns[column] = struct{}{}
+		}
+	}
+
+	for column := range chunks {
+		columns[column] = struct{}{}
+	}
+
+	if w.lob.TruncatedColumn != "" {
+		if name, _, ok := lookupColumn(payload, w.lob.TruncatedColumn); ok {
+			columns[name] = struct{}{}
+		}
+	}
+
+	result := make([]string, 0, len(columns))
+	for column := range columns {
+		result = append(result, column)
+	}
+
+	sort.Strings(result)
+
+	return result
+}
+
+// diffPayload returns sorted columns whose values differ between the payload before the change and
+// the payload after the change. If there's no payload before the change, it returns nil.
+// Fields missing in the payload after the change are added to it as NULLs, unless keepMissingFields is set.
+func (w *Writer) diffPayload(
+	schema *tableSchema,
+	beforeData sdk.Data,
+	after sdk.StructuredData,
+) ([]string, error) {
+	before, err := w.structurizePayload(schema, beforeData)
+	if err != nil {
+		return nil, fmt.Errorf("structurize payload before: %w", err)
+	}
+
+	if before == nil {
+		return nil, nil
+	}
+
+	changed := make([]string, 0)
+
+	for column, value := range after {
+		if beforeValue, ok := before[column]; !ok || !reflect.DeepEqual(beforeValue, value) {
+			changed = append(changed, column)
+		}
+	}
+
+	if !w.keepMissingFields {
+		for column, value := range before {
+			if _, ok := after[column]; ok || value == nil {
+				continue
+			}
+
+			after[column] = nil
+			changed = append(changed, column)
+		}
+	}
+
+	sort.Strings(changed)
+
+	return changed, nil
+}
+
+// Insert appends records as new rows without matching them by a key.
+// Consecutive records that target the same table with the same set of columns
+// are written by a single multi-row INSERT statement.
+// Columns whose values are always generated by the database are omitted.
+// It returns the number of records written before an error, i.e. the records preceding the failed statement.
+func (w *Writer) Insert(ctx context.Context, records []sdk.Record) (int, error) {
+	var (
+		tableName string
+		columns   []string
+		rows      [][]any
+		// first is an index of the first record of the rows, the preceding records have been written.
+		first int
+	)
+
+	flush := func() error {
+		if len(rows) == 0 {
+			return nil
+		}
+
+		query, args := w.buildInsertQuery(tableName, columns, rows)
+
+		err := w.retry(ctx, "insert", func() error {
+			_, err := w.execContext(ctx, w.db, query, args...)
+
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("exec insert: %w",
+				db2errors.WithRecordIndex(db2errors.WithTable(err, tableName), first))
+		}
+
+		rows = nil
+
+		return nil
+	}
+
+	for i, record := range records {
+		recordTable := w.getTableName(record.Metadata)
+
+		schema, err := w.getSchema(ctx, recordTable)
+		if err != nil {
+			return first, fmt.Errorf("get table schema: %w", err)
+		}
+
+		payload, err := w.structurizePayload(schema, record.Payload.After)
+		if err != nil {
+			return first, fmt.Errorf("structurize payload: %w", err)
+		}
+
+		if err = w.addAuditColumns(record, payload); err != nil {
+			return first, fmt.Errorf("add audit columns: %w", err)
+		}
+
+		w.addLOBTruncatedColumn(payload)
+
+		// rows are appended without matching, so the created table has no primary key.
+		schema, err = w.createTable(ctx, w.db, recordTable, schema, inferColumnTypes(payload), nil)
+		if err != nil {
+			return first, fmt.Errorf("create table: %w", err)
+		}
+
+		schema, err = w.checkUnknownColumns(ctx, w.db, recordTable, schema, payload)
+		if err != nil {
+			return first, fmt.Errorf("check unknown columns: %w", err)
+		}
+
+		payload, err = coltypes.ConvertStructureData(ctx, schema.columnTypes, payload, w.convertOptions)
+		if err != nil {
+			return first, fmt.Errorf("convert structure data: %w", err)
+		}
+
+		schema.omitGenerated(payload)
+
+		// appended rows can't be matched, so LOB chunks can't be appended to them.
+		if chunks := w.limitLOBs(ctx, recordTable, schema, payload); len(chunks) > 0 {
+			return first, ErrLOBChunksNotSupported
+		}
+
+		// if payload is empty return empty payload error
+		if len(payload) == 0 {
+			return first, ErrEmptyPayload
+		}
+
+		recordColumns, values := w.extractColumnsAndValues(payload)
+
+		if recordTable != tableName || !equalColumns(recordColumns, columns) ||
+			(len(rows)+1)*len(columns) > maxPlaceholders {
+			if err = flush(); err != nil {
+				return first, err
+			}
+
+			tableName, columns, first = recordTable, recordColumns, i
+		}
+
+		rows = append(rows, values)
+	}
+
+	if err := flush(); err != nil {
+		return first, err
+	}
+
+	return len(records), nil
+}
+
+// WriteSCD2 writes records to a slowly changing dimension (Type 2) table within a single transaction.
+// Create, update and snapshot records close the current version of the row and insert a new one,
+// delete records only close the current version of the row.
+func (w *Writer) WriteSCD2(ctx context.Context, records []sdk.Record) error {
+	return w.retry(ctx, "scd2 transaction", func() error {
+		if err := w.prepareSCD2Tables(ctx, records); err != nil {
+			return err
+		}
+
+		return w.writeSCD2(ctx, records)
+	})
+}
+
+// prepareSCD2Tables creates and evolves the tables of the records outside the transaction, since the DDL
+// and the REORG of an altered table must not run within the uncommitted transaction that writes the rows.
+func (w *Writer) prepareSCD2Tables(ctx context.Context, records []sdk.Record) error {
+	if !w.autoCreateTable && w.unknownColumns != config.UnknownColumnsEvolve {
+		return nil
+	}
+
+	for i, record := range records {
+		if record.Operation == sdk.OperationDelete {
+			continue
+		}
+
+		if err := w.prepareSCD2Table(ctx, record); err != nil {
+			return fmt.Errorf("prepare %s: %w", record.Operation.String(), db2errors.WithRecordIndex(err, i))
+		}
+	}
+
+	return nil
+}
+
+// prepareSCD2Table creates the table of the record, if it doesn't exist, and adds the columns
+// for the record's fields that don't exist in the table.
+func (w *Writer) prepareSCD2Table(ctx context.Context, record sdk.Record) error {
+	tableName := w.getTableName(record.Metadata)
+
+	schema, err := w.getSchema(ctx, tableName)
+	if err != nil {
+		return fmt.Errorf("get table schema: %w", err)
+	}
+
+	key, keyColumn, payload, err := w.scd2Payload(schema, record)
+	if err != nil {
+		return err
+	}
+
+	// the table keeps all versions of the rows, so they're identified by the key and the validity start.
+	schema, err = w.createTable(ctx, w.db, tableName, schema, w.scd2ColumnTypes(key, keyColumn, payload),
+		[]string{keyColumn, w.scd2.ValidFrom})
+	if err != nil {
+		return fmt.Errorf("create table: %w", err)
+	}
+
+	if _, err = w.checkUnknownColumns(ctx, w.db, tableName, schema, payload); err != nil {
+		return fmt.Errorf("check unknown columns: %w", err)
+	}
+
+	return nil
+}
+
+// scd2Payload returns the structurized key of the record, its key column, and the structurized payload
+// with the audit columns, which is nil for delete records.
+func (w *Writer) scd2Payload(
+	schema *tableSchema,
+	record sdk.Record,
+) (sdk.StructuredData, string, sdk.StructuredData, error) {
+	key, err := w.structurizeKey(record.Key)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("structurize key: %w", err)
+	}
+
+	keyColumn, err := w.getKeyColumn(key)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("get key column: %w", err)
+	}
+
+	if record.Operation == sdk.OperationDelete {
+		return key, keyColumn, nil, nil
+	}
+
+	payload, err := w.structurizePayload(schema, record.Payload.After)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("structurize payload: %w", err)
+	}
+
+	if err = w.addAuditColumns(record, payload); err != nil {
+		return nil, "", nil, fmt.Errorf("add audit columns: %w", err)
+	}
+
+	w.addLOBTruncatedColumn(payload)
+
+	return key, keyColumn, payload, nil
+}
+
+// scd2ColumnTypes returns type definitions of the columns of a created SCD2 table.
+func (w *Writer) scd2ColumnTypes(
+	key sdk.StructuredData,
+	keyColumn string,
+	payload sdk.StructuredData,
+) map[string]string {
+	columns := inferColumnTypes(payload)
+	if value, ok := key[keyColumn]; ok {
+		columns[strings.ToUpper(keyColumn)] = coltypes.InferType(value)
+	}
+
+	columns[w.scd2.ValidFrom] = scd2ValidityType
+	columns[w.scd2.ValidTo] = scd2ValidityType
+	columns[w.scd2.Current] = scd2CurrentType
+
+	return columns
+}
+
+// writeSCD2 writes the records within a single transaction.
+func (w *Writer) writeSCD2(ctx context.Context, records []sdk.Record) error {
+	tx, err := w.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // the rollback after the commit does nothing
+
+	// validFrom contains validity starts of the last versions of the keys written within the batch.
+	validFrom := make(map[string]time.Time)
+
+	for i, record := range records {
+		if err = w.writeSCD2Record(ctx, tx, record, validFrom); err != nil {
+			return fmt.Errorf("write %s: %w", record.Operation.String(), db2errors.WithRecordIndex(err, i))
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// writeSCD2Record closes the current version of the row and, unless the record is a delete,
+// inserts a new current version of the row. The tables are created and evolved before the transaction.
+// The validFrom contains validity starts of the last versions of the keys written within the batch.
+func (w *Writer) writeSCD2Record(
+	ctx context.Context,
+	tx *sql.Tx,
+	record sdk.Record,
+	validFrom map[string]time.Time,
+) error {
+	tableName := w.getTableName(record.Metadata)
+
+	schema, err := w.getSchema(ctx, tableName)
+	if err != nil {
+		return fmt.Errorf("get table schema: %w", err)
+	}
+
+	key, keyColumn, payload, err := w.scd2Payload(schema, record)
+	if err != nil {
+		return err
+	}
+
+	if record.Operation != sdk.OperationDelete {
+		schema, err = w.checkUnknownColumns(ctx, tx, tableName, schema, payload)
+		if err != nil {
+			return fmt.Errorf("check unknown columns: %w", err)
+		}
+
+		payload, err = coltypes.ConvertStructureData(ctx, schema.columnTypes, payload, w.convertOptions)
+		if err != nil {
+			return fmt.Errorf("convert structure data: %w", err)
+		}
+
+		if len(payload) == 0 {
+			return ErrEmptyPayload
+		}
+	}
+
+	// the payload's key is already converted, the record's key is converted the same way.
+	keyValue, ok := key[keyColumn]
+	if ok {
+		keyValue, err = w.convertKeyValue(ctx, schema, keyColumn, keyValue)
+		if err != nil {
+			return fmt.Errorf("convert key: %w", err)
+		}
+	} else if _, keyValue, ok = lookupColumn(payload, keyColumn); !ok {
+		return ErrEmptyKey
+	}
+
+	validAt := uniqueValidAt(validFrom, tableName, keyValue, getValidAt(record.Metadata))
+
+	query, args := w.buildCloseVersionQuery(tableName, keyColumn, keyValue, validAt)
+
+	if _, err = w.execContext(ctx, tx, query, args...); err != nil {
+		return fmt.Errorf("exec close version: %w", db2errors.WithTable(err, tableName))
+	}
+
+	if record.Operation == sdk.OperationDelete {
+		return nil
+	}
+
+	// the record's key and validity columns take precedence over the payload.
+	for _, column := range []string{keyColumn, w.scd2.ValidFrom, w.scd2.ValidTo, w.scd2.Current} {
+		if name, _, ok := lookupColumn(payload, column); ok {
+			delete(payload, name)
+		}
+	}
+
+	schema.omitGenerated(payload)
+
+	payload[keyColumn] = keyValue
+	payload[w.scd2.ValidFrom] = validAt
+	payload[w.scd2.ValidTo] = nil
+	payload[w.scd2.Current] = 1
+
+	chunks := w.limitLOBs(ctx, tableName, schema, payload)
+
+	columns, values := w.extractColumnsAndValues(payload)
+
+	query, args = w.buildInsertQuery(tableName, columns, [][]any{values})
+
+	if _, err = w.execContext(ctx, tx, query, args...); err != nil {
+		return fmt.Errorf("exec insert version: %w", db2errors.WithTable(err, tableName))
+	}
+
+	match := sdk.StructuredData{keyColumn: keyValue, w.scd2.ValidFrom: validAt}
+	if err = w.appendLOBChunks(ctx, tx, tableName, schema, match, chunks); err != nil {
+		return fmt.Errorf("append lob chunks: %w", err)
+	}
+
+	return nil
+}
+
+// convertKeyValue converts the value of the key column to the type of the column.
+func (w *Writer) convertKeyValue(ctx context.Context, schema *tableSchema, keyColumn string, keyValue any) (any, error) {
+	key, err := coltypes.ConvertStructureData(ctx, schema.columnTypes,
+		sdk.StructuredData{keyColumn: keyValue}, w.convertOptions)
+	if err != nil {
+		return nil, fmt.Errorf("convert structure data: %w", err)
+	}
+
+	return key[keyColumn], nil
+}
+
+// uniqueValidAt returns the validity start of the key's new version, truncated to microseconds, the precision
+// of DB2 timestamps. If a version of the key has been written within the batch at the same or a later time,
+// the new version starts a microsecond after it, so the versions don't violate the primary key of the table.
+// The validFrom contains validity starts of the last versions of the keys written within the batch.
+func uniqueValidAt(validFrom map[string]time.Time, table string, keyValue any, validAt time.Time) time.Time {
+	id := fmt.Sprintf("%s\x00%v", strings.ToUpper(table), keyValue)
+
+	validAt = validAt.Truncate(time.Microsecond)
+	if last, ok := validFrom[id]; ok && !validAt.After(last) {
+		validAt = last.Add(time.Microsecond)
+	}
+
+	validFrom[id] = validAt
+
+	return validAt
+}
+
+// buildCloseVersionQuery generates an SQL UPDATE statement query,
+// that closes the current version of the row with the provided key.
+func (w *Writer) buildCloseVersionQuery(table, keyColumn string, keyValue any, validTo time.Time) (string, []any) {
+	ub := sqlbuilder.NewUpdateBuilder()
+
+	ub.Update(table)
+	ub.Set(
+		ub.Assign(w.scd2.ValidTo, validTo),
+		ub.Assign(w.scd2.Current, 0),
+	)
+	ub.Where(
+		ub.Equal(keyColumn, keyValue),
+		ub.Equal(w.scd2.Current, 1),
+	)
+
+	query, args := ub.Build()
+
+	return query, args
+}
+
 // buildDeleteQuery generates an SQL DELETE statement query,
 // based on the provided table, keyColumn and keyValue.
 func (w *Writer) buildDeleteQuery(table string, keyColumn string, keyValue any) (string, []any) {
@@ -183,6 +1007,25 @@ func (w *Writer) buildDeleteQuery(table string, keyColumn string, keyValue any)
 	return query, args
 }
 
+// buildVersionedDeleteQuery generates an SQL DELETE statement query, that deletes a row
+// only if its stored version is not newer than the provided versionValue.
+func (w *Writer) buildVersionedDeleteQuery(table, keyColumn string, keyValue, versionValue any) (string, []any) {
+	db := sqlbuilder.NewDeleteBuilder()
+
+	db.DeleteFrom(table)
+	db.Where(
+		db.Equal(keyColumn, keyValue),
+		db.Or(
+			db.IsNull(w.versionColumn),
+			db.LessEqualThan(w.versionColumn, versionValue),
+		),
+	)
+
+	query, args := db.Build()
+
+	return query, args
+}
+
 // getKeyColumn returns either the first key within the Key structured data
 // or the default key configured value for key.
 func (w *Writer) getKeyColumn(key sdk.StructuredData) (string, error) {
@@ -197,14 +1040,46 @@ func (w *Writer) getKeyColumn(key sdk.StructuredData) (string, error) {
 	return w.keyColumn, nil
 }
 
+// structurizePayload converts the payload to sdk.StructuredData, applies the field mapping to it
+// and flattens it if enabled.
+// Nested objects are kept as they are if the table has a string column with the object's name.
+func (w *Writer) structurizePayload(schema *tableSchema, data sdk.Data) (sdk.StructuredData, error) {
+	payload, err := w.structurizeData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	payload = w.fieldMapping.apply(payload)
+
+	if w.flatten {
+		payload = flatten(payload, schema.columnTypes, w.flattenSeparator)
+	}
+
+	return payload, nil
+}
+
+// structurizeKey converts the key to sdk.StructuredData and renames its fields according to the field mapping.
+func (w *Writer) structurizeKey(data sdk.Data) (sdk.StructuredData, error) {
+	key, err := w.structurizeData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return w.fieldMapping.rename(key), nil
+}
+
 // structurizeData converts sdk.Data to sdk.StructuredData.
 func (w *Writer) structurizeData(data sdk.Data) (sdk.StructuredData, error) {
 	if data == nil || len(data.Bytes()) == 0 {
 		return nil, nil
 	}
 
+	// numbers are decoded as json.Number, so they keep all their digits.
+	decoder := json.NewDecoder(bytes.NewReader(data.Bytes()))
+	decoder.UseNumber()
+
 	structuredData := make(sdk.StructuredData)
-	if err := json.Unmarshal(data.Bytes(), &structuredData); err != nil {
+	if err := decoder.Decode(&structuredData); err != nil {
 		return nil, fmt.Errorf("unmarshal data into structured data: %w", err)
 	}
 
@@ -212,44 +1087,76 @@ func (w *Writer) structurizeData(data sdk.Data) (sdk.StructuredData, error) {
 }
 
 // extractColumnsAndValues turns the payload into slices of
-// columns and values for inserting into db2.
+// columns and values for inserting into db2. Columns are sorted by name.
 func (w *Writer) extractColumnsAndValues(payload sdk.StructuredData) ([]string, []any) {
-	var (
-		columns []string
-		values  []any
-	)
-
-	for key, value := range payload {
+	columns := make([]string, 0, len(payload))
+	for key := range payload {
 		columns = append(columns, key)
-		values = append(values, value)
+	}
+
+	sort.Strings(columns)
+
+	values := make([]any, len(columns))
+	for i, column := range columns {
+		values[i] = payload[column]
 	}
 
 	return columns, values
 }
 
+// buildInsertQuery generates a multi-row SQL INSERT statement query,
+// based on the provided table, columns and rows of values.
+func (w *Writer) buildInsertQuery(table string, columns []string, rows [][]any) (string, []any) {
+	rowPlaceholders := make([]string, len(rows))
+	args := make([]any, 0, len(rows)*len(columns))
+
+	for i, values := range rows {
+		rowPlaceholders[i] = fmt.Sprintf("(%s)", setPlaceholders(values))
+		args = append(args, values...)
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s",
+		table,
+		strings.Join(columns, ", "),
+		strings.Join(rowPlaceholders, ", "),
+	)
+
+	return query, args
+}
+
+// buildUpsertQuery generates an SQL MERGE statement query. Matched rows are updated
+// by the updateColumns only, if there are no updateColumns matched rows are left unchanged.
 func (w *Writer) buildUpsertQuery(
 	table, key string,
-	columns []string,
+	columns, updateColumns []string,
 	values []any,
 ) (string, error) {
 	if len(columns) != len(values) {
 		return "", ErrColumnsValuesLenMismatch
 	}
 
+	var matched string
+	if len(updateColumns) > 0 {
+		matched = fmt.Sprintf(`
+			WHEN MATCHED%s THEN
+				%s`,
+			w.setMatchedCondition(),
+			setUpdateQuery(updateColumns),
+		)
+	}
+
 	q := fmt.Sprintf(`
 		MERGE INTO %s AS tab
 		USING (VALUES
 				(%s)
 			) AS merge (%s)
-			ON tab.{KEY_ID} = merge.{KEY_ID}
-			WHEN MATCHED THEN
-				%s
+			ON tab.{KEY_ID} = merge.{KEY_ID}%s
 			WHEN NOT MATCHED THEN
 				%s`,
 		table,
-		setPlaceholders(len(values)),
+		setPlaceholders(values),
 		strings.Join(columns, ","),
-		setUpdateQuery(columns),
+		matched,
 		setInsertQuery(columns),
 	)
 
@@ -258,10 +1165,24 @@ func (w *Writer) buildUpsertQuery(
 	return q, nil
 }
 
-func setPlaceholders(count int) string {
-	sl := make([]string, count)
-	for i := range sl {
+// setMatchedCondition returns an additional condition for the WHEN MATCHED clause,
+// that allows updating a row only if the incoming version is greater than the stored one.
+func (w *Writer) setMatchedCondition() string {
+	if w.versionColumn == "" {
+		return ""
+	}
+
+	return strings.ReplaceAll(" AND (tab.{col} IS NULL OR merge.{col} > tab.{col})", "{col}", w.versionColumn)
+}
+
+// setPlaceholders returns placeholders of the values, XML documents are parsed by their placeholders.
+func setPlaceholders(values []any) string {
+	sl := make([]string, len(values))
+	for i, value := range values {
 		sl[i] = placeholder
+		if _, ok := value.(coltypes.XML); ok {
+			sl[i] = xmlPlaceholder
+		}
 	}
 
 	return strings.Join(sl, ",")
@@ -285,3 +1206,75 @@ func setInsertQuery(columns []string) string {
 
 	return fmt.Sprintf(" INSERT (%s) VALUES(%s) ", strings.Join(columns, ", "), strings.Join(str, ", "))
 }
+
+// equalColumns reports whether two sorted slices of columns are equal.
+func equalColumns(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// lookupColumn returns a key and a value of the column from the data ignoring the case.
+func lookupColumn(data sdk.StructuredData, column string) (string, any, bool) {
+	if value, ok := data[column]; ok {
+		return column, value, true
+	}
+
+	for key, value := range data {
+		if strings.EqualFold(key, column) {
+			return key, value, true
+		}
+	}
+
+	return "", nil, false
+}
+
+// getValidAt returns the time when the record was created in the source,
+// or the current time if the record's metadata doesn't contain it.
+func getValidAt(metadata sdk.Metadata) time.Time {
+	createdAt, err := metadata.GetCreatedAt()
+	if err != nil {
+		return time.Now().UTC()
+	}
+
+	return createdAt.UTC()
+}
+
+// getAuditValue returns a value of the audit column source from the record.
+// Time values of the record's metadata are parsed into time.Time.
+func getAuditValue(record sdk.Record, source string) (any, error) {
+	switch source {
+	case config.AuditSourceOperation:
+		return record.Operation.String(), nil
+	case config.AuditSourcePosition:
+		return string(record.Position), nil
+	case config.AuditSourceCreatedAt:
+		source = config.AuditSourcePrefixMetadata + sdk.MetadataCreatedAt
+	case config.AuditSourceReadAt:
+		source = config.AuditSourcePrefixMetadata + sdk.MetadataReadAt
+	}
+
+	key := strings.TrimPrefix(source, config.AuditSourcePrefixMetadata)
+
+	value, ok := record.Metadata[key]
+	if !ok || value == "" {
+		return nil, nil
+	}
+
+	switch key {
+	case sdk.MetadataCreatedAt:
+		return record.Metadata.GetCreatedAt()
+	case sdk.MetadataReadAt:
+		return record.Metadata.GetReadAt()
+	default:
+		return value, nil
+	}
+}
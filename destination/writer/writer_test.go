@@ -0,0 +1,327 @@
+// Copyright © 2022 Meroxa, Inc & Yalantis.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	sdk "github.com/conduitio/conduit-connector-sdk"
+
+	"github.com/conduitio-labs/conduit-connector-db2/coltypes"
+)
+
+func TestWriter_buildInsertQuery(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		table     string
+		columns   []string
+		rows      [][]any
+		wantQuery string
+		wantArgs  []any
+	}{
+		{
+			name:      "single row",
+			table:     "USERS",
+			columns:   []string{"ID", "NAME"},
+			rows:      [][]any{{1, "John"}},
+			wantQuery: "INSERT INTO USERS (ID, NAME) VALUES (?,?)",
+			wantArgs:  []any{1, "John"},
+		},
+		{
+			name:      "multiple rows",
+			table:     "USERS",
+			columns:   []string{"NAME"},
+			rows:      [][]any{{"John"}, {"Jane"}, {nil}},
+			wantQuery: "INSERT INTO USERS (NAME) VALUES (?), (?), (?)",
+			wantArgs:  []any{"John", "Jane", nil},
+		},
+		{
+			name:    "xml",
+			table:   "USERS",
+			columns: []string{"ID", "PROFILE"},
+			rows:    [][]any{{1, coltypes.XML("<a/>")}, {2, nil}},
+			wantQuery: "INSERT INTO USERS (ID, PROFILE) VALUES " +
+				"(?,XMLPARSE(DOCUMENT CAST(? AS CLOB(2G)) PRESERVE WHITESPACE)), (?,?)",
+			wantArgs: []any{1, coltypes.XML("<a/>"), 2, nil},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := &Writer{}
+
+			query, args := w.buildInsertQuery(tt.table, tt.columns, tt.rows)
+			if query != tt.wantQuery {
+				t.Errorf("buildInsertQuery() query = %q, want %q", query, tt.wantQuery)
+			}
+
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("buildInsertQuery() args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestWriter_buildUpsertQuery_versionColumn(t *testing.T) {
+	t.Parallel()
+
+	w := &Writer{versionColumn: "VERSION"}
+
+	query, err := w.buildUpsertQuery("USERS", "ID", []string{"ID", "VERSION"}, []string{"ID", "VERSION"}, []any{1, 2})
+	if err != nil {
+		t.Fatalf("buildUpsertQuery() error = %v", err)
+	}
+
+	want := "WHEN MATCHED AND (tab.VERSION IS NULL OR merge.VERSION > tab.VERSION) THEN"
+	if !strings.Contains(query, want) {
+		t.Errorf("buildUpsertQuery() = %q, must contain %q", query, want)
+	}
+}
+
+func TestWriter_buildVersionedDeleteQuery(t *testing.T) {
+	t.Parallel()
+
+	w := &Writer{versionColumn: "VERSION"}
+
+	query, args := w.buildVersionedDeleteQuery("USERS", "ID", 1, 2)
+
+	wantQuery := "DELETE FROM USERS WHERE ID = ? AND (VERSION IS NULL OR VERSION <= ?)"
+	if query != wantQuery {
+		t.Errorf("buildVersionedDeleteQuery() query = %q, want %q", query, wantQuery)
+	}
+
+	if !reflect.DeepEqual(args, []any{1, 2}) {
+		t.Errorf("buildVersionedDeleteQuery() args = %v, want %v", args, []any{1, 2})
+	}
+}
+
+func TestWriter_diffPayload(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name              string
+		keepMissingFields bool
+		before            sdk.Data
+		after             sdk.StructuredData
+		wantColumns       []string
+		wantAfter         sdk.StructuredData
+	}{
+		{
+			name:        "no payload before",
+			before:      nil,
+			after:       sdk.StructuredData{"ID": json.Number("1"), "NAME": "John"},
+			wantColumns: nil,
+			wantAfter:   sdk.StructuredData{"ID": json.Number("1"), "NAME": "John"},
+		},
+		{
+			name:        "changed and missing fields",
+			before:      sdk.StructuredData{"ID": 1, "NAME": "John", "AGE": 30, "CITY": "Kyiv"},
+			after:       sdk.StructuredData{"ID": json.Number("1"), "NAME": "Jane", "AGE": json.Number("30")},
+			wantColumns: []string{"CITY", "NAME"},
+			wantAfter:   sdk.StructuredData{"ID": json.Number("1"), "NAME": "Jane", "AGE": json.Number("30"), "CITY": nil},
+		},
+		{
+			name:              "keep missing fields",
+			keepMissingFields: true,
+			before:            sdk.StructuredData{"ID": 1, "NAME": "John", "CITY": "Kyiv"},
+			after:             sdk.StructuredData{"ID": json.Number("1"), "NAME": "John"},
+			wantColumns:       []string{},
+			wantAfter:         sdk.StructuredData{"ID": json.Number("1"), "NAME": "John"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := &Writer{keepMissingFields: tt.keepMissingFields}
+
+			columns, err := w.diffPayload(&tableSchema{}, tt.before, tt.after)
+			if err != nil {
+				t.Fatalf("diffPayload() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(columns, tt.wantColumns) {
+				t.Errorf("diffPayload() columns = %v, want %v", columns, tt.wantColumns)
+			}
+
+			if !reflect.DeepEqual(tt.after, tt.wantAfter) {
+				t.Errorf("diffPayload() after = %v, want %v", tt.after, tt.wantAfter)
+			}
+		})
+	}
+}
+
+func TestWriter_buildUpsertQuery_noUpdateColumns(t *testing.T) {
+	t.Parallel()
+
+	w := &Writer{}
+
+	query, err := w.buildUpsertQuery("USERS", "ID", []string{"ID", "NAME"}, []string{}, []any{1, "John"})
+	if err != nil {
+		t.Fatalf("buildUpsertQuery() error = %v", err)
+	}
+
+	if strings.Contains(query, "WHEN MATCHED") {
+		t.Errorf("buildUpsertQuery() = %q, must not contain WHEN MATCHED", query)
+	}
+}
+
+func TestWriter_buildCloseVersionQuery(t *testing.T) {
+	t.Parallel()
+
+	w := &Writer{scd2: SCD2Columns{ValidFrom: "VALID_FROM", ValidTo: "VALID_TO", Current: "IS_CURRENT"}}
+	validTo := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	query, args := w.buildCloseVersionQuery("USERS", "ID", 1, validTo)
+
+	wantQuery := "UPDATE USERS SET VALID_TO = ?, IS_CURRENT = ? WHERE ID = ? AND IS_CURRENT = ?"
+	if query != wantQuery {
+		t.Errorf("buildCloseVersionQuery() query = %q, want %q", query, wantQuery)
+	}
+
+	wantArgs := []any{validTo, 0, 1, 1}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("buildCloseVersionQuery() args = %v, want %v", args, wantArgs)
+	}
+}
+
+func TestUniqueValidAt(t *testing.T) {
+	t.Parallel()
+
+	createdAt := time.Date(2022, 10, 1, 12, 0, 0, 1500, time.UTC)
+	validFrom := make(map[string]time.Time)
+
+	tests := []struct {
+		table    string
+		keyValue any
+		validAt  time.Time
+		want     time.Time
+	}{
+		{table: "USERS", keyValue: 1, validAt: createdAt, want: createdAt.Truncate(time.Microsecond)},
+		{table: "USERS", keyValue: 2, validAt: createdAt, want: createdAt.Truncate(time.Microsecond)},
+		{table: "users", keyValue: 1, validAt: createdAt, want: createdAt.Add(500)},
+		{table: "USERS", keyValue: 1, validAt: createdAt.Add(-time.Second), want: createdAt.Add(1500)},
+		{table: "ADMINS", keyValue: 1, validAt: createdAt, want: createdAt.Truncate(time.Microsecond)},
+		{table: "USERS", keyValue: 1, validAt: createdAt.Add(time.Second), want: createdAt.Add(time.Second - 500)},
+	}
+
+	// the versions are written in order, so the cases depend on each other.
+	for _, tt := range tests {
+		if got := uniqueValidAt(validFrom, tt.table, tt.keyValue, tt.validAt); !got.Equal(tt.want) {
+			t.Errorf("uniqueValidAt(%q, %v) = %v, want %v", tt.table, tt.keyValue, got, tt.want)
+		}
+	}
+}
+
+func TestWriter_convertKeyValue(t *testing.T) {
+	t.Parallel()
+
+	w := &Writer{}
+	schema := &tableSchema{columnTypes: map[string]coltypes.ColumnType{"ID": {Name: "INTEGER"}}}
+
+	got, err := w.convertKeyValue(context.Background(), schema, "id", "42")
+	if err != nil {
+		t.Fatalf("convertKeyValue() error = %v", err)
+	}
+
+	if got != int64(42) {
+		t.Errorf("convertKeyValue() = %v (%T), want %v", got, got, int64(42))
+	}
+}
+
+func TestWriter_addAuditColumns(t *testing.T) {
+	t.Parallel()
+
+	readAt := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	record := sdk.Record{
+		Position:  sdk.Position("pos-1"),
+		Operation: sdk.OperationUpdate,
+		Metadata: sdk.Metadata{
+			sdk.MetadataReadAt: strconv.FormatInt(readAt.UnixNano(), 10),
+			"source":           "crm",
+		},
+	}
+
+	w := &Writer{
+		auditColumns: map[string]string{
+			"SYNC_OP":     "operation",
+			"SYNC_POS":    "position",
+			"SYNC_TS":     "metadata.opencdc.readAt",
+			"SYNC_SOURCE": "metadata.source",
+			"SYNC_MISSED": "metadata.missed",
+		},
+	}
+
+	payload := sdk.StructuredData{"ID": 1, "sync_op": "overwritten"}
+
+	if err := w.addAuditColumns(record, payload); err != nil {
+		t.Fatalf("addAuditColumns() error = %v", err)
+	}
+
+	want := sdk.StructuredData{
+		"ID":          1,
+		"SYNC_OP":     "update",
+		"SYNC_POS":    "pos-1",
+		"SYNC_TS":     readAt.Local(),
+		"SYNC_SOURCE": "crm",
+		"SYNC_MISSED": nil,
+	}
+
+	if !reflect.DeepEqual(payload, want) {
+		t.Errorf("addAuditColumns() payload = %v, want %v", payload, want)
+	}
+}
+
+// blockingExecer is an execQuerier whose statements run until their context is done.
+type blockingExecer struct{}
+
+func (blockingExecer) QueryContext(context.Context, string, ...any) (*sql.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+func (blockingExecer) ExecContext(ctx context.Context, _ string, _ ...any) (sql.Result, error) {
+	<-ctx.Done()
+
+	return nil, ctx.Err()
+}
+
+func TestWriter_execContext_queryTimeout(t *testing.T) {
+	t.Parallel()
+
+	w := &Writer{queryTimeout: 10 * time.Millisecond}
+
+	_, err := w.execContext(context.Background(), blockingExecer{}, "DELETE FROM CLIENTS")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("execContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
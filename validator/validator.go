@@ -44,9 +44,19 @@ func init() {
 
 // Validate validates a struct.
 func Validate(data any) error {
+	return formatErrors(data, validate.Struct(data))
+}
+
+// ValidateExcept validates a struct except for the provided fields.
+// Fields may be provided in a namespaced fashion relative to the struct, e.g. "Config.Key".
+func ValidateExcept(data any, fields ...string) error {
+	return formatErrors(data, validate.StructExcept(data, fields...))
+}
+
+// formatErrors converts validation errors into human-readable errors.
+func formatErrors(data any, validationErr error) error {
 	var err error
 
-	validationErr := validate.Struct(data)
 	if validationErr != nil {
 		if errors.Is(validationErr, (*validator.InvalidValidationError)(nil)) {
 			return fmt.Errorf("validate struct: %w", validationErr)
@@ -66,6 +76,8 @@ func Validate(data any) error {
 				err = multierr.Append(err, gteErr(fieldName, e.Param()))
 			case "lte":
 				err = multierr.Append(err, lteErr(fieldName, e.Param()))
+			case "oneof":
+				err = multierr.Append(err, oneofErr(fieldName, e.Param()))
 			}
 		}
 	}
@@ -152,3 +164,8 @@ func gteErr(name, gte string) error {
 func lteErr(name, lte string) error {
 	return fmt.Errorf("%q value must be less than or equal to %s", name, lte)
 }
+
+// oneofErr returns the formatted oneof error.
+func oneofErr(name, oneof string) error {
+	return fmt.Errorf("%q value must be one of %s", name, strings.Join(strings.Fields(oneof), ", "))
+}